@@ -0,0 +1,19 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The dl command manages the Go versions installed by the goX wrapper
+// commands.
+//
+// To install, run:
+//
+//	$ go install github.com/LetFu/dl/cmd/dl@latest
+//
+// Run 'dl' with no arguments for a list of commands.
+package main
+
+import "github.com/LetFu/dl/internal/version"
+
+func main() {
+	version.RunDL()
+}
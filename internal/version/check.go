@@ -0,0 +1,184 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// Statuses of archives in a mirror check.
+const (
+	checkOK         = "ok"
+	checkMissing    = "missing"    // the mirror does not serve the archive
+	checkMismatched = "mismatched" // the archive or its checksum differs from upstream
+	checkStale      = "stale"      // the archive is fine, but the mirror's index is out of date
+	checkError      = "error"      // the mirror could not be checked
+)
+
+// A checkResult is the outcome of checking one archive in a mirror.
+type checkResult struct {
+	Filename string `json:"filename"`
+	Status   string `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// A checkReport is the outcome of checking a mirror against upstream.
+type checkReport struct {
+	Mirror   string        `json:"mirror"`
+	Upstream string        `json:"upstream"`
+	Checked  int           `json:"checked"`
+	Problems int           `json:"problems"`
+	Results  []checkResult `json:"results"`
+}
+
+// checkMirror checks that mirror serves the release archives that c
+// selects from upstream's release index, with the sizes and checksums
+// upstream lists. Without download, it trusts the size the mirror
+// reports and the mirror's .sha256 files; with download, it also reads
+// each archive in full and checksums the bytes actually served.
+// Archives the mirror serves correctly but that its own release index
+// lacks or misdescribes are reported as stale.
+func (in *Installer) checkMirror(ctx context.Context, upstream, mirror source, c *mirrorConfig, download bool) (*checkReport, error) {
+	releases, err := upstream.list(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing upstream releases: %w", err)
+	}
+	files := c.selectFiles(releases)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no release archives match")
+	}
+
+	report := &checkReport{Mirror: mirror.url(""), Upstream: upstream.url("")}
+	indexed := map[string]releaseFile{}
+	mirrorReleases, err := mirror.list(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		report.Results = append(report.Results, checkResult{indexFile, checkStale, fmt.Sprintf("cannot read release index: %v", err)})
+		indexed = nil
+	}
+	for _, r := range mirrorReleases {
+		for _, f := range r.Files {
+			indexed[f.Filename] = f
+		}
+	}
+
+	for i := range files {
+		f := &files[i]
+		if f.SHA256 == "" {
+			if f.SHA256, err = upstream.checksum(ctx, f.Filename); err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Filename, err)
+			}
+		}
+		in.logf("Checking %s ...", f.Filename)
+		r := in.checkFile(ctx, mirror, f, download)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if r.Status == checkOK && indexed != nil {
+			if g, ok := indexed[f.Filename]; !ok {
+				r = checkResult{f.Filename, checkStale, "missing from release index"}
+			} else if g.SHA256 != f.SHA256 || g.Size != 0 && f.Size != 0 && g.Size != f.Size {
+				r = checkResult{f.Filename, checkStale, "release index lists a different size or checksum"}
+			}
+		}
+		report.Checked++
+		report.Results = append(report.Results, r)
+	}
+	for _, r := range report.Results {
+		if r.Status != checkOK {
+			report.Problems++
+		}
+	}
+	return report, nil
+}
+
+// checkFile checks the archive f in mirror.
+func (in *Installer) checkFile(ctx context.Context, mirror source, f *releaseFile, download bool) checkResult {
+	result := func(status, format string, args ...any) checkResult {
+		return checkResult{f.Filename, status, fmt.Sprintf(format, args...)}
+	}
+	size, err := mirror.stat(ctx, f.Filename)
+	if errors.Is(err, ErrNotFound) {
+		return result(checkMissing, "")
+	} else if err != nil {
+		return result(checkError, "%v", err)
+	}
+	if size >= 0 && f.Size > 0 && size != f.Size {
+		return result(checkMismatched, "size is %d bytes; want %d", size, f.Size)
+	}
+	sum, err := mirror.checksum(ctx, f.Filename)
+	var nerr *NetworkError
+	if errors.Is(err, fs.ErrNotExist) || errors.As(err, &nerr) && nerr.StatusCode == 404 {
+		return result(checkMissing, "no %s.sha256 file", f.Filename)
+	} else if err != nil {
+		return result(checkError, "%v", err)
+	}
+	if sum != f.SHA256 {
+		return result(checkMismatched, ".sha256 file has %s; want %s", sum, f.SHA256)
+	}
+	if !download {
+		return result(checkOK, "")
+	}
+
+	rc, _, _, err := mirror.open(ctx, f.Filename, 0, -1)
+	if err != nil {
+		return result(checkError, "%v", err)
+	}
+	defer rc.Close()
+	hash := sha256.New()
+	n, err := io.Copy(hash, rc)
+	if err != nil {
+		return result(checkError, "%v", err)
+	}
+	if f.Size > 0 && n != f.Size {
+		return result(checkMismatched, "served %d bytes; want %d", n, f.Size)
+	}
+	if got := fmt.Sprintf("%x", hash.Sum(nil)); got != f.SHA256 {
+		return result(checkMismatched, "served bytes have SHA-256 %s; want %s", got, f.SHA256)
+	}
+	return result(checkOK, "")
+}
+
+// err returns a *mirrorProblemsError if the report has problems, and
+// nil otherwise.
+func (r *checkReport) err() error {
+	if r.Problems > 0 {
+		return &mirrorProblemsError{r.Problems, r.Checked}
+	}
+	return nil
+}
+
+// write writes the report to w, as JSON if asJSON is set and otherwise
+// as a line per problem followed by a summary.
+func (r *checkReport) write(w io.Writer, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(r)
+	}
+	var b strings.Builder
+	for _, res := range r.Results {
+		if res.Status == checkOK {
+			continue
+		}
+		fmt.Fprintf(&b, "%-10s %s", res.Status, res.Filename)
+		if res.Detail != "" {
+			fmt.Fprintf(&b, ": %s", res.Detail)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "checked %d archives in %s: %d problems\n", r.Checked, r.Mirror, r.Problems)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckMirror(t *testing.T) {
+	linux := platform{"linux", "amd64"}
+	up := newFakeUpstream(t, []string{"go1.21.0", "go1.21.1", "go1.22.0", "go1.22.1"}, []platform{linux})
+	upSrv := httptest.NewServer(up)
+	defer upSrv.Close()
+	in := &Installer{Progress: quietProgress{}, Logf: t.Logf}
+	upstream, err := in.newSource(upSrv.URL + "/go/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	versions, _ := parseVersionFilter("<1.22.1")
+	ctx := context.Background()
+	if err := in.mirror(ctx, upstream, dir, &mirrorConfig{versions: versions}); err != nil {
+		t.Fatal(err)
+	}
+	mirrorSrv := httptest.NewServer(http.StripPrefix("/go/", http.FileServer(http.Dir(dir))))
+	defer mirrorSrv.Close()
+	mirror, err := in.newSource(mirrorSrv.URL + "/go/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := func(v string) string { return v + ".linux-amd64.tar.gz" }
+	// go1.21.0 is truncated, and go1.21.1 has the right size but is
+	// corrupt, which only a download detects.
+	if err := os.WriteFile(filepath.Join(dir, name("go1.21.0")), up.files[name("go1.21.0")][:100], 0644); err != nil {
+		t.Fatal(err)
+	}
+	corrupt := append([]byte(nil), up.files[name("go1.21.1")]...)
+	corrupt[len(corrupt)/2] ^= 0xff
+	if err := os.WriteFile(filepath.Join(dir, name("go1.21.1")), corrupt, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// go1.22.1 was copied in by hand, without updating the index.
+	if err := os.WriteFile(filepath.Join(dir, name("go1.22.1")), up.files[name("go1.22.1")], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name("go1.22.1")+".sha256"), up.files[name("go1.22.1")+".sha256"], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := func(r *checkReport) string {
+		var list []string
+		for _, res := range r.Results {
+			list = append(list, res.Filename+" "+res.Status)
+		}
+		return strings.Join(list, "\n")
+	}
+	tests := []struct {
+		download bool
+		want     []string
+		problems int
+	}{
+		{false, []string{"go1.22.1 stale", "go1.22.0 ok", "go1.21.1 ok", "go1.21.0 mismatched"}, 2},
+		{true, []string{"go1.22.1 stale", "go1.22.0 ok", "go1.21.1 mismatched", "go1.21.0 mismatched"}, 3},
+	}
+	for _, tt := range tests {
+		report, err := in.checkMirror(ctx, upstream, mirror, &mirrorConfig{}, tt.download)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []string
+		for _, w := range tt.want {
+			v, status, _ := strings.Cut(w, " ")
+			want = append(want, name(v)+" "+status)
+		}
+		if got := statuses(report); got != strings.Join(want, "\n") {
+			t.Errorf("checkMirror(download=%v):\n%s\nwant:\n%s", tt.download, got, strings.Join(want, "\n"))
+		}
+		if report.Problems != tt.problems {
+			t.Errorf("checkMirror(download=%v) found %d problems; want %d", tt.download, report.Problems, tt.problems)
+		}
+	}
+
+	// Archives missing from the mirror are reported as missing, and
+	// the JSON report carries every result.
+	os.Remove(filepath.Join(dir, name("go1.22.0")))
+	report, err := in.checkMirror(ctx, upstream, mirror, &mirrorConfig{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := report.write(&buf, true); err != nil {
+		t.Fatal(err)
+	}
+	var decoded checkReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("JSON report: %v\n%s", err, buf.Bytes())
+	}
+	if decoded.Checked != 4 || decoded.Problems != 3 || decoded.Results[1].Status != checkMissing {
+		t.Errorf("JSON report = %+v; want 4 checked, 3 problems, go1.22.0 missing", decoded)
+	}
+	// Problems exit with their own code, not that of other failures.
+	if err := report.err(); exitCode(err) != exitMirror {
+		t.Errorf("report error %v has exit code %d; want %d", err, exitCode(err), exitMirror)
+	}
+	if err := (&checkReport{Checked: 4}).err(); err != nil {
+		t.Errorf("report without problems has error %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The wrapper commands are configured through GODL_* settings. Each
+// setting is read from the environment first and then from the config
+// file, which holds one KEY=VALUE pair per line in the same form as the
+// go command's env file. Blank lines and lines starting with '#' are
+// ignored.
+//
+// The config file is $GODL_CONFIG if set, and otherwise
+// godl/config under os.UserConfigDir.
+
+var (
+	configMu   sync.Mutex
+	configPath string            // file that configVals was read from
+	configVals map[string]string // settings in configPath
+	warned     map[string]bool   // "KEY=value" pairs already warned about
+)
+
+// configFile returns the path of the config file, or "" if it cannot be
+// determined.
+func configFile() string {
+	if f := os.Getenv("GODL_CONFIG"); f != "" {
+		return f
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "godl", "config")
+}
+
+// readConfig parses the KEY=VALUE lines of the named config file.
+// A missing or unreadable file yields an empty configuration.
+func readConfig(file string) map[string]string {
+	vals := map[string]string{}
+	f, err := os.Open(file)
+	if err != nil {
+		return vals
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		vals[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return vals
+}
+
+// getenv returns the value of the named setting, preferring the
+// environment over the config file. A variable set to the empty string
+// in the environment overrides the config file, restoring the default.
+func getenv(key string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	file := configFile()
+	if file == "" {
+		return ""
+	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	if configVals == nil || configPath != file {
+		configPath, configVals = file, readConfig(file)
+	}
+	return configVals[key]
+}
+
+// warnSetting warns through in.logf, once, that the named setting has
+// the invalid value v and is ignored.
+func (in *Installer) warnSetting(key, v string, err error) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	if warned[key+"="+v] {
+		return
+	}
+	if warned == nil {
+		warned = map[string]bool{}
+	}
+	warned[key+"="+v] = true
+	in.logf("ignoring invalid setting %s=%s: %v", key, v, err)
+}
+
+// getenvBool reports whether the named setting is set to a true value
+// such as "1" or "true". It warns about values that are not booleans.
+func (in *Installer) getenvBool(key string) bool {
+	v := getenv(key)
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		in.warnSetting(key, v, errors.New("want true or false"))
+	}
+	return b
+}
+
+// durationSetting returns the named setting parsed as a time.Duration,
+// or def if it is unset or invalid. It warns about invalid values.
+func (in *Installer) durationSetting(key string, def time.Duration) time.Duration {
+	v := getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		in.warnSetting(key, v, errors.New("want a duration with a unit, such as 30s or 5m"))
+		return def
+	}
+	return d
+}
+
+// intSetting returns the named setting parsed as an integer, or def if
+// it is unset or invalid. It warns about invalid values.
+func (in *Installer) intSetting(key string, def int) int {
+	v := getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		in.warnSetting(key, v, errors.New("want an integer"))
+		return def
+	}
+	return n
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetenv(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	if err := os.WriteFile(a, []byte("# comment\nGODL_TEST_SETTING = from-a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("GODL_TEST_SETTING=from-b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GODL_CONFIG", a)
+	t.Setenv("GODL_TEST_SETTING", "") // restored after the test
+	os.Unsetenv("GODL_TEST_SETTING")
+	if got := getenv("GODL_TEST_SETTING"); got != "from-a" {
+		t.Errorf("getenv from config file a = %q; want from-a", got)
+	}
+	// Another config file is read, not the first one cached.
+	t.Setenv("GODL_CONFIG", b)
+	if got := getenv("GODL_TEST_SETTING"); got != "from-b" {
+		t.Errorf("getenv from config file b = %q; want from-b", got)
+	}
+	t.Setenv("GODL_TEST_SETTING", "from-env")
+	if got := getenv("GODL_TEST_SETTING"); got != "from-env" {
+		t.Errorf("getenv with environment set = %q; want from-env", got)
+	}
+	// An empty environment variable overrides the config file.
+	t.Setenv("GODL_TEST_SETTING", "")
+	if got := getenv("GODL_TEST_SETTING"); got != "" {
+		t.Errorf("getenv with empty environment variable = %q; want \"\"", got)
+	}
+}
+
+func TestInvalidSettings(t *testing.T) {
+	t.Setenv("GODL_CONFIG", filepath.Join(t.TempDir(), "no-such-config"))
+	var buf bytes.Buffer
+	in := &Installer{Logf: func(format string, args ...any) { fmt.Fprintf(&buf, format+"\n", args...) }}
+
+	t.Setenv("GODL_TEST_INT", "four")
+	t.Setenv("GODL_TEST_DURATION", "10")
+	t.Setenv("GODL_TEST_BOOL", "yes")
+	if n := in.intSetting("GODL_TEST_INT", 4); n != 4 {
+		t.Errorf("intSetting = %d; want default 4", n)
+	}
+	if d := in.durationSetting("GODL_TEST_DURATION", time.Minute); d != time.Minute {
+		t.Errorf("durationSetting = %v; want default 1m", d)
+	}
+	if in.getenvBool("GODL_TEST_BOOL") {
+		t.Errorf("getenvBool of invalid value = true")
+	}
+	in.intSetting("GODL_TEST_INT", 4)
+	for _, want := range []string{"GODL_TEST_INT=four", "GODL_TEST_DURATION=10", "GODL_TEST_BOOL=yes"} {
+		if n := strings.Count(buf.String(), want); n != 1 {
+			t.Errorf("warned about %s %d times; want once:\n%s", want, n, buf.String())
+		}
+	}
+
+	buf.Reset()
+	t.Setenv("GODL_TEST_INT", "8")
+	t.Setenv("GODL_TEST_DURATION", "10s")
+	if n, d := in.intSetting("GODL_TEST_INT", 4), in.durationSetting("GODL_TEST_DURATION", 0); n != 8 || d != 10*time.Second || buf.Len() != 0 {
+		t.Errorf("valid settings = %d, %v, warnings %q; want 8, 10s and none", n, d, buf.String())
+	}
+}
@@ -0,0 +1,352 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Installed versions can share the files they have in common through a
+// content-addressed store in their SDK root. Each distinct file is kept
+// once in the store, as a blob named by its SHA-256 and whether it is
+// executable, and the files of each version are links to the blobs:
+// copy-on-write clones (reflinks) where the file system supports them,
+// and hard links otherwise. Hard-linked files share their permissions
+// and modification time with every other link, so blobs are made
+// read-only.
+//
+// Each deduplicated version has a manifest listing the SHA-256 of its
+// files, which is what keeps blobs in the store: pruneStore removes
+// blobs that no manifest lists. Removing a version, or a blob that a
+// version still links to, never loses data, since the other links keep
+// the contents; at worst, files stop being shared.
+
+// storeDir is the name of the directory in an SDK root that holds the
+// store.
+const storeDir = ".store"
+
+// manifestFile is the name of the file, next to receiptFile, that lists
+// the SHA-256 of each file of an installed version, in the format that
+// sha256sum prints and checks.
+const manifestFile = ".files.sha256"
+
+// DedupeModes lists the modes accepted by the GODL_DEDUPE setting.
+var DedupeModes = []string{"off", "auto", "reflink", "hardlink"}
+
+// checkDedupeMode reports an error if mode is not one of DedupeModes.
+// The empty mode is "off".
+func checkDedupeMode(mode string) error {
+	if mode == "" {
+		return nil
+	}
+	for _, m := range DedupeModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown dedupe mode %q; want one of %s", mode, strings.Join(DedupeModes, ", "))
+}
+
+// dedupeEnabled reports whether mode deduplicates files.
+func dedupeEnabled(mode string) bool {
+	return mode != "" && mode != "off"
+}
+
+// A manifestEntry is a line of a manifest.
+type manifestEntry struct {
+	sum  string // hex SHA-256
+	exec bool   // whether the file is executable
+	rel  string // slash-separated path relative to the version directory
+}
+
+// blob returns the name of the blob for e, relative to the store.
+func (e manifestEntry) blob() string {
+	name := e.sum
+	if e.exec {
+		name += "-x"
+	}
+	return path.Join(e.sum[:2], name)
+}
+
+// isMetadata reports whether the path rel, relative to a version
+// directory, is a file that the installer added rather than part of the
+// distribution: a dot file or the archive at the top level.
+func isMetadata(rel string) bool {
+	if strings.Contains(rel, "/") {
+		return false
+	}
+	return strings.HasPrefix(rel, ".") || strings.HasSuffix(rel, ".tar.gz") || strings.HasSuffix(rel, ".zip") || strings.HasSuffix(rel, ".partial")
+}
+
+// hashFile returns the hex SHA-256 of the named file.
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// scanFiles returns the manifest entries of the regular files of the
+// version installed in dir, sorted by path.
+func scanFiles(dir string) ([]manifestEntry, error) {
+	var list []manifestEntry
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." || !d.Type().IsRegular() || isMetadata(rel) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sum, err := hashFile(name)
+		if err != nil {
+			return err
+		}
+		list = append(list, manifestEntry{sum: sum, exec: fi.Mode().Perm()&0111 != 0, rel: rel})
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].rel < list[j].rel })
+	return list, err
+}
+
+// readManifest reads the manifest of the version installed in dir. The
+// executable bits are recorded as a "*" before the path, which is
+// sha256sum's marker for binary mode and is ignored when checking.
+func readManifest(dir string) ([]manifestEntry, error) {
+	f, err := os.Open(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var list []manifestEntry
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		sum, rel, ok := strings.Cut(s.Text(), " ")
+		if !ok || len(sum) != 64 || len(rel) < 2 {
+			return nil, fmt.Errorf("%s: malformed line %q", filepath.Join(dir, manifestFile), s.Text())
+		}
+		list = append(list, manifestEntry{sum: sum, exec: rel[0] == '*', rel: rel[1:]})
+	}
+	return list, s.Err()
+}
+
+// writeManifest writes the manifest of the version installed in dir.
+func writeManifest(dir string, list []manifestEntry) error {
+	var b strings.Builder
+	for _, e := range list {
+		mark := " "
+		if e.exec {
+			mark = "*"
+		}
+		fmt.Fprintf(&b, "%s %s%s\n", e.sum, mark, e.rel)
+	}
+	return os.WriteFile(filepath.Join(dir, manifestFile), []byte(b.String()), groupPerm(0644, isGroupShared(dir)))
+}
+
+// dedupeStats counts what dedupe did.
+type dedupeStats struct {
+	Files  int   // regular files in the version
+	Shared int   // files that are links to blobs other versions share
+	Saved  int64 // bytes no longer stored twice by this run
+}
+
+// dedupe links the files of the version installed in dir to blobs in
+// the store of its SDK root, adding blobs for files that have none, in
+// the given mode, and writes the version's manifest. A read-only
+// version is made writable while its files are replaced.
+func dedupe(dir, mode string) (stats dedupeStats, err error) {
+	if err := checkDedupeMode(mode); err != nil {
+		return stats, err
+	}
+	if r, err := ReadReceipt(dir); err == nil && r.ReadOnly {
+		if err := makeWritable(dir); err != nil {
+			return stats, err
+		}
+		defer func() {
+			if roErr := makeReadOnly(dir); err == nil {
+				err = roErr
+			}
+		}()
+	}
+	root := filepath.Dir(dir)
+	store := filepath.Join(root, storeDir)
+	if err := mkdirVersion(store, isSharedRoot(root)); err != nil {
+		return stats, err
+	}
+	list, err := scanFiles(dir)
+	if err != nil {
+		return stats, err
+	}
+	for _, e := range list {
+		stats.Files++
+		file := filepath.Join(dir, filepath.FromSlash(e.rel))
+		blob := filepath.Join(store, filepath.FromSlash(e.blob()))
+		shared, saved, err := linkBlob(file, blob, mode)
+		if err != nil {
+			return stats, fmt.Errorf("%s: %v", e.rel, err)
+		}
+		if shared {
+			stats.Shared++
+		}
+		stats.Saved += saved
+	}
+	return stats, writeManifest(dir, list)
+}
+
+// errNoReflink reports that the file system cannot clone files.
+var errNoReflink = errors.New("file system does not support reflinks")
+
+// linkBlob makes file a link to blob, which holds the same contents, or
+// adds file to the store as blob if there is no such blob. It reports
+// whether file was already a blob and how many bytes replacing it with
+// a link saved.
+func linkBlob(file, blob, mode string) (shared bool, saved int64, err error) {
+	ffi, err := os.Stat(file)
+	if err != nil {
+		return false, 0, err
+	}
+	bfi, err := os.Stat(blob)
+	if err == nil && os.SameFile(ffi, bfi) {
+		return true, 0, nil
+	}
+	if err == nil && bfi.Size() == ffi.Size() {
+		// Replace file with a link to the blob, through a temporary
+		// name so that file is never missing.
+		tmp := file + ".godl-dedupe"
+		os.Remove(tmp)
+		err = linkFile(tmp, blob, mode)
+		if err == nil {
+			if err := os.Rename(tmp, file); err != nil {
+				os.Remove(tmp)
+				return false, 0, err
+			}
+			return true, ffi.Size(), nil
+		}
+		if !os.IsNotExist(err) {
+			return false, 0, err
+		}
+		// A concurrent prune removed the blob. Add it again.
+	}
+
+	if err := os.MkdirAll(filepath.Dir(blob), groupPerm(0755, isGroupShared(filepath.Dir(filepath.Dir(blob))))); err != nil {
+		return false, 0, err
+	}
+	tmp, err := tempName(filepath.Dir(blob), filepath.Base(blob))
+	if err != nil {
+		return false, 0, err
+	}
+	if err := linkFile(tmp, file, mode); err != nil {
+		return false, 0, err
+	}
+	if err := os.Chmod(tmp, ffi.Mode().Perm()&^0222); err != nil {
+		os.Remove(tmp)
+		return false, 0, err
+	}
+	if err := os.Rename(tmp, blob); err != nil {
+		os.Remove(tmp)
+		return false, 0, err
+	}
+	return false, 0, nil
+}
+
+// tempName returns an unused name for a temporary file in dir.
+func tempName(dir, prefix string) (string, error) {
+	f, err := os.CreateTemp(dir, prefix+".tmp-")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	return f.Name(), os.Remove(f.Name())
+}
+
+// linkFile makes dst a link to src in the given mode: a reflink if
+// possible in "auto" mode, and otherwise a hard link, or only one of
+// the two in "reflink" and "hardlink" mode.
+func linkFile(dst, src, mode string) error {
+	if mode != "hardlink" {
+		err := reflink(dst, src)
+		if err == nil || mode == "reflink" || !errors.Is(err, errNoReflink) {
+			return err
+		}
+	}
+	return os.Link(src, dst)
+}
+
+// pruneStore removes the blobs in the store of the SDK root that no
+// manifest of a version in the root lists, and returns how many it
+// removed and their size.
+func pruneStore(root string) (removed int, freed int64, err error) {
+	store := filepath.Join(root, storeDir)
+	if _, err := os.Stat(store); os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return 0, 0, err
+	}
+	keep := map[string]bool{}
+	for _, e := range entries {
+		if !e.IsDir() || e.Name() == storeDir {
+			continue
+		}
+		list, err := readManifest(filepath.Join(root, e.Name()))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, m := range list {
+			keep[m.blob()] = true
+		}
+	}
+	err = filepath.WalkDir(store, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(store, name)
+		if err != nil || keep[filepath.ToSlash(rel)] {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(name); err != nil {
+			// Windows refuses to remove read-only files.
+			if os.Chmod(name, 0644) != nil || os.Remove(name) != nil {
+				return err
+			}
+		}
+		removed++
+		freed += fi.Size()
+		return nil
+	})
+	return removed, freed, err
+}
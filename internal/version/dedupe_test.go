@@ -0,0 +1,188 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// writeVersion writes an installed version with the given files, of
+// mode 0644 or 0755 for names in bin/, to root.
+func writeVersion(t *testing.T, root, version string, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(root, version)
+	for name, data := range files {
+		perm := os.FileMode(0644)
+		if filepath.Dir(name) == "bin" {
+			perm = 0755
+		}
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(data), perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{unpackedOkay, receiptFile, archiveName(version)} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(version), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func sameFile(t *testing.T, a, b string) bool {
+	t.Helper()
+	afi, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bfi, err := os.Stat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return os.SameFile(afi, bfi)
+}
+
+func TestDedupe(t *testing.T) {
+	root := t.TempDir()
+	v1 := writeVersion(t, root, "go1.99.1", map[string]string{
+		"VERSION":          "go1.99.1",
+		"bin/go":           "binary",
+		"src/fmt/print.go": "package fmt",
+		"src/old.go":       "package old",
+		"lib/same.txt":     "binary", // same contents as bin/go, but not executable
+	})
+	v2 := writeVersion(t, root, "go1.99.2", map[string]string{
+		"VERSION":          "go1.99.2",
+		"bin/go":           "binary",
+		"src/fmt/print.go": "package fmt",
+		"src/new.go":       "package new",
+	})
+
+	stats, err := dedupe(v1, "hardlink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (dedupeStats{Files: 5}); stats != want {
+		t.Errorf("dedupe(go1.99.1) = %+v; want %+v", stats, want)
+	}
+	stats, err = dedupe(v2, "hardlink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (dedupeStats{Files: 4, Shared: 2, Saved: int64(len("binary") + len("package fmt"))}); stats != want {
+		t.Errorf("dedupe(go1.99.2) = %+v; want %+v", stats, want)
+	}
+	// Running it again finds everything shared already.
+	if stats, err := dedupe(v2, "auto"); err != nil || stats.Shared != 4 || stats.Saved != 0 {
+		t.Errorf("dedupe(go1.99.2) again = %+v, %v; want all 4 shared, nothing saved", stats, err)
+	}
+
+	for _, name := range []string{"bin/go", "src/fmt/print.go"} {
+		if !sameFile(t, filepath.Join(v1, name), filepath.Join(v2, name)) {
+			t.Errorf("%s is not shared", name)
+		}
+	}
+	if runtime.GOOS != "windows" && sameFile(t, filepath.Join(v1, "bin/go"), filepath.Join(v1, "lib/same.txt")) {
+		t.Errorf("executable bin/go and lib/same.txt are shared")
+	}
+	if data, err := os.ReadFile(filepath.Join(v2, "bin/go")); err != nil || string(data) != "binary" {
+		t.Errorf("bin/go = %q, %v; want binary", data, err)
+	}
+
+	manifest, err := readManifest(v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanned, err := scanFiles(v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(manifest, scanned) || len(manifest) != 4 {
+		t.Errorf("manifest = %+v; want the 4 files of the distribution %+v", manifest, scanned)
+	}
+
+	countBlobs := func() int {
+		n := 0
+		filepath.Walk(filepath.Join(root, storeDir), func(_ string, fi os.FileInfo, err error) error {
+			if err == nil && fi.Mode().IsRegular() {
+				n++
+			}
+			return nil
+		})
+		return n
+	}
+	if n := countBlobs(); n != 7 {
+		t.Errorf("store has %d blobs; want 7", n)
+	}
+
+	// Removing a version keeps the files it shared, and drops the
+	// blobs only it used.
+	if err := RemoveVersion(v1); err != nil {
+		t.Fatal(err)
+	}
+	if n := countBlobs(); n != 4 {
+		t.Errorf("after removing go1.99.1, store has %d blobs; want 4", n)
+	}
+	if data, err := os.ReadFile(filepath.Join(v2, "src/fmt/print.go")); err != nil || string(data) != "package fmt" {
+		t.Errorf("after removing go1.99.1, src/fmt/print.go = %q, %v", data, err)
+	}
+
+	// A blob pruned from under a version is added back by the next
+	// dedupe.
+	os.Remove(filepath.Join(v2, manifestFile))
+	if removed, _, err := pruneStore(root); err != nil || removed != 4 {
+		t.Errorf("pruneStore = %d, %v; want 4 removed", removed, err)
+	}
+	if stats, err := dedupe(v2, "hardlink"); err != nil || stats.Shared != 0 {
+		t.Errorf("dedupe after prune = %+v, %v; want nothing shared", stats, err)
+	}
+	if n := countBlobs(); n != 4 {
+		t.Errorf("after dedupe, store has %d blobs; want 4", n)
+	}
+	if err := RemoveVersion(v2); err != nil {
+		t.Fatal(err)
+	}
+	if n := countBlobs(); n != 0 {
+		t.Errorf("after removing every version, store has %d blobs; want 0", n)
+	}
+}
+
+func TestInstallDedupe(t *testing.T) {
+	dir := t.TempDir()
+	for _, v := range []string{"go1.99.1", "go1.99.2"} {
+		archive := testArchive(t, map[string]string{"VERSION": v, "bin/go": "binary", "src/fmt/print.go": "package fmt"})
+		if err := os.WriteFile(filepath.Join(dir, archiveName(v)), archive, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, archiveName(v)+".sha256"), []byte(sha256Hex(archive)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := t.TempDir()
+	in := &Installer{BaseURL: fileURL(dir), ChecksumURL: ChecksumFromMirror, Progress: quietProgress{}, Logf: t.Logf, Dedupe: "auto"}
+	for _, v := range []string{"go1.99.1", "go1.99.2"} {
+		if err := in.Install(context.Background(), filepath.Join(root, v), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !sameFile(t, filepath.Join(root, "go1.99.1/src/fmt/print.go"), filepath.Join(root, "go1.99.2/src/fmt/print.go")) {
+		t.Errorf("src/fmt/print.go is not shared between versions")
+	}
+	if _, err := readManifest(filepath.Join(root, "go1.99.2")); err != nil {
+		t.Errorf("reading manifest: %v", err)
+	}
+	in.Dedupe = "copy"
+	if err := in.Install(context.Background(), filepath.Join(root, "go1.99.3"), "go1.99.3"); err == nil {
+		t.Errorf("Install with dedupe mode %q succeeded; want error", in.Dedupe)
+	}
+}
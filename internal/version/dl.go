@@ -0,0 +1,390 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A dlCommand is a subcommand of the dl command.
+type dlCommand struct {
+	name  string
+	usage string // arguments, after the command name
+	short string // one-line description
+	run   func(args []string) error
+}
+
+// dlCommands is initialized in init to break the dependency cycle
+// through dlUsage.
+var dlCommands []*dlCommand
+
+func init() {
+	dlCommands = []*dlCommand{
+		{"check-mirror", "[-versions constraints] [-platforms list] [-upstream url] [-unstable] [-download] [-json] url", "check a mirror against the upstream release index", runCheckMirror},
+		{"dedupe", "[-mode mode] [-root dir] [version ...]", "share identical files between installed versions", runDedupe},
+		{"lock", "add [-file file] [-platforms list] [-from url] version ...", "pin the checksums of versions in go-toolchains.lock", runLock},
+		{"migrate", "[-from dir] [-to dir] [version ...]", "move installed versions to another SDK root", runMigrate},
+		{"mirror", "-out dir [-versions constraints] [-platforms list] [-from url] [-unstable] [-verify]", "build a static download site", runMirror},
+		{"sbom", "[-format format] [-o file] [-archive file | version]", "write a software bill of materials for a version", runSBOM},
+		{"serve", "-dir dir [-addr addr] [-upstream url] [-readonly]", "serve a caching proxy or mirror of the download site", runServe},
+		{"sync", "[-file file] [-root dir]", "install the versions that go-toolchains.lock pins", runSync},
+	}
+}
+
+// RunDL runs the dl command, which manages the versions installed by
+// the wrapper commands.
+func RunDL() {
+	log.SetFlags(0)
+	log.SetPrefix("dl: ")
+
+	if len(os.Args) < 2 {
+		dlUsage()
+	}
+	for _, c := range dlCommands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fatal(c.name, err)
+			}
+			os.Exit(0)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "dl: unknown command %q\n", os.Args[1])
+	dlUsage()
+}
+
+func dlUsage() {
+	var b strings.Builder
+	b.WriteString("usage: dl <command> [arguments]\n\nThe commands are:\n\n")
+	for _, c := range dlCommands {
+		fmt.Fprintf(&b, "\t%-14s %s\n", c.name, c.short)
+	}
+	fmt.Fprint(os.Stderr, b.String())
+	os.Exit(2)
+}
+
+// newFlagSet returns a flag set for the named command that prints the
+// command's usage on error.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		for _, c := range dlCommands {
+			if c.name == name {
+				fmt.Fprintf(os.Stderr, "usage: dl %s %s\n", c.name, c.usage)
+			}
+		}
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func runCheckMirror(args []string) error {
+	fs := newFlagSet("check-mirror")
+	versions := fs.String("versions", "", "check versions matching `constraints`, such as '>=1.21,<1.23' (default all)")
+	platforms := fs.String("platforms", "", "check archives for a comma-separated `list` of GOOS/GOARCH pairs (default all)")
+	upstream := fs.String("upstream", DefaultBaseURL, "compare with the release index of the source at `url`")
+	unstable := fs.Bool("unstable", false, "include betas and release candidates")
+	download := fs.Bool("download", false, "download each archive and check the bytes served, not just the size and .sha256 file")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	c := &mirrorConfig{unstable: *unstable}
+	var err error
+	if c.versions, err = parseVersionFilter(*versions); err != nil {
+		return err
+	}
+	if c.platforms, err = parsePlatforms(*platforms); err != nil {
+		return err
+	}
+
+	in := newInstaller()
+	in.Progress = quietProgress{}
+	up, err := in.newSource(*upstream)
+	if err != nil {
+		return err
+	}
+	mirror, err := in.newSource(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	report, err := in.checkMirror(ctx, up, mirror, c, *download)
+	if err != nil {
+		return err
+	}
+	if err := report.write(os.Stdout, *asJSON); err != nil {
+		return err
+	}
+	return report.err()
+}
+
+func runDedupe(args []string) error {
+	mode := getenv("GODL_DEDUPE")
+	if !dedupeEnabled(mode) {
+		mode = "auto"
+	}
+	fs := newFlagSet("dedupe")
+	fs.StringVar(&mode, "mode", mode, "how to share files: auto, reflink or hardlink")
+	root := fs.String("root", "", "deduplicate versions in the SDK root `dir` (default all SDK roots)")
+	fs.Parse(args)
+	if err := checkDedupeMode(mode); err != nil || !dedupeEnabled(mode) {
+		fs.Usage()
+		os.Exit(2)
+	}
+	roots := []string{*root}
+	if *root == "" {
+		var err error
+		if roots, err = SDKRoots(); err != nil {
+			return err
+		}
+	}
+	named := map[string]bool{}
+	for _, v := range fs.Args() {
+		named[v] = true
+	}
+	for _, root := range roots {
+		entries, err := os.ReadDir(root)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			dir := filepath.Join(root, e.Name())
+			if !IsInstalled(dir) || len(named) > 0 && !named[e.Name()] {
+				continue
+			}
+			stats, err := dedupe(dir, mode)
+			if err != nil {
+				return fmt.Errorf("%s: %v", dir, err)
+			}
+			fmt.Printf("%s: %d files, %d shared, saved %s\n", dir, stats.Files, stats.Shared, fmtSize(stats.Saved))
+		}
+		removed, freed, err := pruneStore(root)
+		if err != nil {
+			return err
+		}
+		if removed > 0 {
+			fmt.Printf("%s: removed %d unused blobs, freeing %s\n", root, removed, fmtSize(freed))
+		}
+	}
+	return nil
+}
+
+func runLock(args []string) error {
+	fs := newFlagSet("lock")
+	file := fs.String("file", "", "update the lockfile `file` (default go-toolchains.lock in the current directory or its nearest parent that has one)")
+	platforms := fs.String("platforms", "", "pin archives for a comma-separated `list` of GOOS/GOARCH pairs (default those already pinned, or the host's)")
+	from := fs.String("from", DefaultBaseURL, "take checksums from the release index of the source at `url`")
+	if len(args) == 0 || args[0] != "add" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	fs.Parse(args[1:])
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var versions []string
+	for _, arg := range fs.Args() {
+		v, err := lockVersion(arg)
+		if err != nil {
+			return err
+		}
+		versions = append(versions, v)
+	}
+	if *file == "" {
+		var err error
+		if *file, err = findLockfile(); err != nil {
+			return err
+		}
+	}
+	list, err := readLockfile(*file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	pl, err := parsePlatforms(*platforms)
+	if err != nil {
+		return err
+	}
+	if len(pl) == 0 {
+		if pl = lockedPlatforms(list); len(pl) == 0 {
+			pl = []platform{hostPlatform()}
+		}
+	}
+
+	in := newInstaller()
+	src, err := in.newSource(*from)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if list, err = in.lockAdd(ctx, src, list, versions, pl); err != nil {
+		return err
+	}
+	return writeLockfile(*file, list)
+}
+
+func runMigrate(args []string) error {
+	root, err := sdkRoot()
+	if err != nil {
+		return err
+	}
+	home, err := homedir()
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("migrate")
+	from := fs.String("from", filepath.Join(home, "sdk"), "SDK root to move versions from")
+	to := fs.String("to", root, "SDK root to move versions to")
+	fs.Parse(args)
+	if filepath.Clean(*from) == filepath.Clean(*to) {
+		return fmt.Errorf("-from and -to are both %s; name the SDK root to move versions to with -to or GODL_SDKROOT", filepath.Clean(*from))
+	}
+	return migrate(*from, *to, fs.Args())
+}
+
+func runMirror(args []string) error {
+	fs := newFlagSet("mirror")
+	out := fs.String("out", "", "write the mirror to `dir`")
+	versions := fs.String("versions", "", "mirror versions matching `constraints`, such as '>=1.21,<1.23' (default all)")
+	platforms := fs.String("platforms", "", "mirror archives for a comma-separated `list` of GOOS/GOARCH pairs (default all)")
+	from := fs.String("from", DefaultBaseURL, "mirror from the source at `url`")
+	unstable := fs.Bool("unstable", false, "include betas and release candidates")
+	verify := fs.Bool("verify", false, "re-verify the checksums of archives already in the mirror")
+	fs.Parse(args)
+	if *out == "" || fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	c := &mirrorConfig{unstable: *unstable, verify: *verify}
+	var err error
+	if c.versions, err = parseVersionFilter(*versions); err != nil {
+		return err
+	}
+	if c.platforms, err = parsePlatforms(*platforms); err != nil {
+		return err
+	}
+
+	in := newInstaller()
+	src, err := in.newSource(*from)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return in.mirror(ctx, src, *out, c)
+}
+
+func runSBOM(args []string) error {
+	fs := newFlagSet("sbom")
+	format := fs.String("format", "spdx-json", "document `format`: "+strings.Join(SBOMFormats, ", "))
+	out := fs.String("o", "", "write the document to `file` (default standard output)")
+	archive := fs.String("archive", "", "describe the release archive `file` instead of an installed version")
+	fs.Parse(args)
+	if *archive == "" && fs.NArg() != 1 || *archive != "" && fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err := checkSBOMFormat(*format); err != nil {
+		return err
+	}
+	var s *sbomInfo
+	var err error
+	if *archive != "" {
+		s, err = archiveSBOM(*archive)
+	} else {
+		v := "go" + strings.TrimPrefix(fs.Arg(0), "go")
+		var dir string
+		if dir, err = Goroot(v); err != nil {
+			return err
+		}
+		s, err = treeSBOM(dir, v, needsSHA1(*format))
+	}
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		return s.write(os.Stdout, *format, time.Now())
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := s.write(f, *format, time.Now()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func runServe(args []string) error {
+	fs := newFlagSet("serve")
+	dir := fs.String("dir", "", "keep archives in `dir`")
+	addr := fs.String("addr", "localhost:8080", "listen on `addr`")
+	upstream := fs.String("upstream", DefaultBaseURL, "fetch missing archives from the source at `url`")
+	readonly := fs.Bool("readonly", false, "only serve the archives already in dir, such as a mirror built by 'dl mirror'")
+	fs.Parse(args)
+	if *dir == "" || fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	in := newInstaller()
+	in.Progress = quietProgress{}
+	var src source
+	if !*readonly {
+		var err error
+		if src, err = in.newSource(*upstream); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(*dir, 0755); err != nil {
+			return err
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return newServer(in, *dir, src).serve(ctx, *addr)
+}
+
+func runSync(args []string) error {
+	fs := newFlagSet("sync")
+	file := fs.String("file", "", "install the versions pinned in `file` (default go-toolchains.lock in the current directory or its nearest parent that has one)")
+	root := fs.String("root", "", "install to the SDK root `dir` (default where the wrapper commands look)")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *file == "" {
+		var err error
+		if *file, err = findLockfile(); err != nil {
+			return err
+		}
+	}
+	list, err := readLockfile(*file)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("%s pins no versions", *file)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return newInstaller().sync(ctx, list, *root)
+}
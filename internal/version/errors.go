@@ -0,0 +1,111 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+var (
+	// ErrNotFound reports that there is no binary release of the
+	// requested version for the host platform.
+	ErrNotFound = errors.New("no such release")
+
+	// ErrNotInstalled reports that the requested version is not
+	// installed.
+	ErrNotInstalled = errors.New("not installed")
+)
+
+// A ChecksumError reports that a downloaded archive does not have the
+// expected SHA-256 checksum.
+type ChecksumError struct {
+	File string // path of the archive
+	Want string // expected SHA-256, in hex
+	Got  string // actual SHA-256, in hex
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s corrupt? does not have expected SHA-256 of %v", e.File, e.Want)
+}
+
+// A NetworkError reports a failure to fetch a URL: either the request
+// failed or was interrupted, or the server responded with an
+// unexpected status.
+type NetworkError struct {
+	URL        string
+	StatusCode int   // HTTP status of the response, or 0 if there was none
+	Err        error // underlying error, if StatusCode is 0
+}
+
+func (e *NetworkError) Error() string {
+	u := redactURL(e.URL)
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s: server returned %d %s", u, e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%s: %v", u, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+// A mirrorProblemsError reports that dl check-mirror found archives
+// that a mirror is missing or serves wrongly.
+type mirrorProblemsError struct {
+	Problems int // archives with problems
+	Checked  int // archives checked
+}
+
+func (e *mirrorProblemsError) Error() string {
+	return fmt.Sprintf("%d of %d archives have problems", e.Problems, e.Checked)
+}
+
+// Exit codes of the wrapper commands and dl. Failures of the wrapper itself
+// use codes from 3 up, so they cannot be confused with the 1 and 2 that
+// the go command exits with and that the wrappers pass through.
+const (
+	exitNotInstalled = 3   // version not installed, and auto-download is off
+	exitNotFound     = 4   // no binary release of the version for the host
+	exitChecksum     = 5   // archive checksum mismatch
+	exitNetwork      = 6   // network failure or unexpected server response
+	exitFailure      = 7   // any other failure, such as a file system error
+	exitMirror       = 8   // dl check-mirror found problems with the mirror
+	exitInterrupted  = 130 // interrupted by the user, as by a shell
+)
+
+// exitCode returns the exit code reporting err.
+func exitCode(err error) int {
+	var (
+		cerr *ChecksumError
+		nerr *NetworkError
+		merr *mirrorProblemsError
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.Is(err, ErrNotInstalled):
+		return exitNotInstalled
+	case errors.Is(err, ErrNotFound):
+		return exitNotFound
+	case errors.As(err, &cerr):
+		return exitChecksum
+	case errors.As(err, &nerr), errors.Is(err, context.DeadlineExceeded):
+		return exitNetwork
+	case errors.As(err, &merr):
+		return exitMirror
+	default:
+		return exitFailure
+	}
+}
+
+// fatal logs err, prefixed by prefix, and exits with the exit code for
+// err.
+func fatal(prefix string, err error) {
+	log.Printf("%s: %v", prefix, err)
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,16 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package version
+
+import "errors"
+
+// canExec reports whether execGo is supported on this platform.
+const canExec = false
+
+func execGo(gobin string, args, env []string) error {
+	return errors.New("exec not supported on this platform")
+}
@@ -0,0 +1,21 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package version
+
+import "syscall"
+
+// canExec reports whether execGo is supported on this platform.
+const canExec = true
+
+// syscallExec is syscall.Exec, replaced in tests.
+var syscallExec = syscall.Exec
+
+// execGo replaces the current process with the go binary at gobin,
+// running it with args and env. It only returns if the exec fails.
+func execGo(gobin string, args, env []string) error {
+	return syscallExec(gobin, append([]string{gobin}, args...), env)
+}
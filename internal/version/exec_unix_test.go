@@ -0,0 +1,54 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package version
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExecCmd(t *testing.T) {
+	var gotPath string
+	var gotArgv, gotEnv []string
+	errExec := errors.New("exec failed")
+	defer func(old func(string, []string, []string) error) { syscallExec = old }(syscallExec)
+	syscallExec = func(path string, argv, env []string) error {
+		gotPath, gotArgv, gotEnv = path, argv, env
+		return errExec
+	}
+
+	t.Setenv("PATH", "/usr/bin")
+	root := t.TempDir()
+	cmd := GoCommand(context.Background(), root, "build", "-o", "out", "./...")
+	if err := execCmd(cmd); err != errExec {
+		t.Fatalf("execCmd = %v; want the error from exec", err)
+	}
+	if gotPath != cmd.Path {
+		t.Errorf("exec path = %q; want %q", gotPath, cmd.Path)
+	}
+	if !reflect.DeepEqual(gotArgv, cmd.Args) {
+		t.Errorf("exec argv = %q; want %q", gotArgv, cmd.Args)
+	}
+	if !reflect.DeepEqual(gotEnv, cmd.Env) {
+		t.Errorf("exec env = %q; want %q", gotEnv, cmd.Env)
+	}
+	var goroot, path string
+	for _, kv := range gotEnv {
+		switch {
+		case strings.HasPrefix(kv, "GOROOT="):
+			goroot = strings.TrimPrefix(kv, "GOROOT=")
+		case strings.HasPrefix(kv, "PATH="):
+			path = strings.TrimPrefix(kv, "PATH=")
+		}
+	}
+	if goroot != root || path != root+"/bin:/usr/bin" {
+		t.Errorf("exec env has GOROOT=%s PATH=%s; want GOROOT=%s PATH=%s/bin:/usr/bin", goroot, path, root, root)
+	}
+}
@@ -0,0 +1,655 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// unpackOptions configures unpackArchive.
+type unpackOptions struct {
+	workers int        // files to write at a time, as unpackWorkers counts them
+	keep    fileFilter // entries to unpack; nil means all
+
+	// logf logs benign errors, such as failures to set modification
+	// times. If nil, log.Printf is used.
+	logf func(format string, args ...any)
+}
+
+// unpackArchive unpacks the provided archive zip or tar.gz file to targetDir,
+// removing the "go/" prefix from file entries.
+func unpackArchive(ctx context.Context, targetDir, archiveFile string, opts unpackOptions, p Progress) error {
+	switch {
+	case strings.HasSuffix(archiveFile, ".zip"):
+		return unpackZip(ctx, targetDir, archiveFile, opts, p)
+	case strings.HasSuffix(archiveFile, ".tar.gz"):
+		return unpackTarGz(ctx, targetDir, archiveFile, opts, p)
+	default:
+		return errors.New("unsupported archive file")
+	}
+}
+
+// unpackWorkers returns the number of files to write at a time when n
+// are asked for: n if positive, and otherwise twice the number of CPUs,
+// up to 16, since writers spend most of their time waiting on the file
+// system.
+func unpackWorkers(n int) int {
+	if n > 0 {
+		return n
+	}
+	n = 2 * runtime.NumCPU()
+	if n > 16 {
+		n = 16
+	}
+	return n
+}
+
+// maxBuffered is the size of the largest file that unpackTarGz reads
+// into memory to hand to a writer. Larger files, which are few, are
+// written as they are decompressed.
+const maxBuffered = 1 << 20
+
+// unpackTarGz is the tar.gz implementation of unpackArchive. The archive
+// can only be decompressed in order, so it is read by one goroutine,
+// which hands the contents of each file to the writers.
+func unpackTarGz(ctx context.Context, targetDir, archiveFile string, opts unpackOptions, p Progress) error {
+	f, err := os.Open(archiveFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	// Report progress through the compressed archive, whose size is
+	// known up front.
+	pw := newProgressWriter(io.Discard, p, PhaseUnpack, 0, fi.Size())
+	zr, err := gzip.NewReader(io.TeeReader(f, pw))
+	if err != nil {
+		return err
+	}
+	x := newExtractor(targetDir, opts)
+	defer x.wait()
+	tr := tar.NewReader(zr)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := entryPath(h.Name)
+		if err != nil {
+			return err
+		}
+		if !x.keep(rel) {
+			if h.Typeflag == tar.TypeReg {
+				x.skipped[rel] = true
+			}
+			continue
+		}
+		switch h.Typeflag {
+		case tar.TypeReg:
+			err = x.tarFile(tr, rel, h)
+		case tar.TypeDir:
+			err = x.mkdir(rel, h.ModTime)
+		case tar.TypeSymlink:
+			err = x.symlink(rel, h.Linkname)
+		case tar.TypeLink:
+			var target string
+			if target, err = entryPath(h.Linkname); err == nil {
+				err = x.hardlink(rel, target)
+			}
+		case tar.TypeXGlobalHeader:
+			// Metadata only, such as the commit ID git archive records.
+		default:
+			err = fmt.Errorf("%s: unsupported file type %v", h.Name, h.FileInfo().Mode().Type())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := x.finish(); err != nil {
+		return err
+	}
+	pw.done()
+	return nil
+}
+
+// tarFile writes the regular file rel from tr, whose header is h: by a
+// writer if it is small enough to buffer, and otherwise directly.
+func (x *extractor) tarFile(tr *tar.Reader, rel string, h *tar.Header) error {
+	abs, perm, err := x.file(rel, h.FileInfo().Mode(), h.ModTime)
+	if err != nil {
+		return err
+	}
+	if err := x.mkdirs(); err != nil {
+		return err
+	}
+	size := h.Size
+	if size > maxBuffered || x.jobs == nil {
+		return writeFile(abs, perm, tr, size)
+	}
+	data, err := io.ReadAll(io.LimitReader(tr, size))
+	if err != nil {
+		return err
+	}
+	return x.submit(func() error {
+		return writeFile(abs, perm, bytes.NewReader(data), size)
+	})
+}
+
+// unpackZip is the zip implementation of unpackArchive. Each writer
+// reads the file it writes from the archive, which allows random access,
+// after all the directories are made.
+func unpackZip(ctx context.Context, targetDir, archiveFile string, opts unpackOptions, p Progress) error {
+	zr, err := zip.OpenReader(archiveFile)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	x := newExtractor(targetDir, opts)
+	defer x.wait()
+	type job struct {
+		f    *zip.File
+		abs  string
+		perm os.FileMode
+	}
+	var jobs []job
+	var total int64
+	for _, f := range zr.File {
+		rel, err := entryPath(f.Name)
+		if err != nil {
+			return err
+		}
+		if !x.keep(rel) {
+			continue
+		}
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			err = x.mkdir(rel, f.Modified)
+		case mode.IsRegular():
+			var abs string
+			var perm os.FileMode
+			if abs, perm, err = x.file(rel, mode, f.Modified); err == nil {
+				jobs = append(jobs, job{f, abs, perm})
+				total += int64(f.UncompressedSize64)
+			}
+		case mode.Type() == os.ModeSymlink:
+			var target string
+			if target, err = zipLink(f); err == nil {
+				err = x.symlink(rel, target)
+			}
+		default:
+			err = fmt.Errorf("%s: unsupported file type %v", f.Name, mode.Type())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := x.mkdirs(); err != nil {
+		return err
+	}
+	pw := newProgressWriter(io.Discard, p, PhaseUnpack, 0, total)
+	progress := &syncWriter{w: pw}
+	for _, j := range jobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		j := j
+		err := x.submit(func() error {
+			rc, err := j.f.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			return writeFile(j.abs, j.perm, io.TeeReader(rc, progress), int64(j.f.UncompressedSize64))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := x.finish(); err != nil {
+		return err
+	}
+	pw.done()
+	return nil
+}
+
+// zipLink returns the target of the symbolic link f.
+func zipLink(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	target, err := io.ReadAll(io.LimitReader(rc, 4096))
+	return string(target), err
+}
+
+// entryPath returns the path, relative to the install directory, of
+// the archive entry with the given name: the name without its "go/"
+// prefix, cleaned, in slash-separated form. It rejects names that would
+// escape the install directory.
+func entryPath(name string) (string, error) {
+	if name == "go" || name == "go/" {
+		// The top-level directory itself.
+		return ".", nil
+	}
+	rel := strings.TrimPrefix(name, "go/")
+	if !validRelPath(name) || !validRelPath(rel) {
+		return "", fmt.Errorf("archive contained invalid name %q", name)
+	}
+	return path.Clean(rel), nil
+}
+
+// validRelPath reports whether p is a safe relative path for an archive
+// entry: not empty or absolute, with no ".." elements, backslashes,
+// volume names or NUL bytes.
+func validRelPath(p string) bool {
+	if p == "" || strings.ContainsAny(p, "\\\x00") || strings.HasPrefix(p, "/") {
+		return false
+	}
+	if i := strings.Index(p, ":"); i >= 0 && !strings.Contains(p[:i], "/") {
+		// A Windows volume name, such as C:.
+		return false
+	}
+	for _, elem := range strings.Split(p, "/") {
+		if elem == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// An extractor writes archive entries below a directory, confining every
+// write to it. Entries are given by slash-separated paths relative to
+// the directory, as returned by entryPath.
+//
+// One goroutine calls the methods, which check and record each entry,
+// while a pool of writers writes file contents concurrently. The
+// directories are made in batches, by mkdirs, before the files in them
+// are handed to the writers. Hard and symbolic links are created and
+// modification times set by finish, once every file is written; symbolic
+// links only after checking that they resolve inside the directory
+// through any other links in the archive, so that no entry can be
+// written through one.
+type extractor struct {
+	dir     string
+	shared  bool
+	keep    fileFilter
+	madeDir map[string]bool
+	newDirs map[string]bool   // directories to make
+	files   map[string]bool   // regular files written
+	skipped map[string]bool   // regular files left out by keep
+	links   map[string]string // symbolic links to create, to their targets
+	hard    [][2]string       // hard links to create, and their targets
+	mtimes  []fileTime        // modification times to set
+	logf    func(format string, args ...any)
+
+	jobs chan func() error // nil if files are written serially
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	err  error // first error of a writer
+}
+
+// A fileTime is the modification time to give a file.
+type fileTime struct {
+	abs   string
+	mtime time.Time
+}
+
+// newExtractor returns an extractor writing to dir as opts configure.
+// The caller must call wait once done with it.
+func newExtractor(dir string, opts unpackOptions) *extractor {
+	x := &extractor{
+		dir:     dir,
+		shared:  isGroupShared(dir),
+		keep:    opts.keep,
+		madeDir: map[string]bool{},
+		newDirs: map[string]bool{},
+		files:   map[string]bool{},
+		skipped: map[string]bool{},
+		links:   map[string]string{},
+		logf:    opts.logf,
+	}
+	if x.keep == nil {
+		x.keep = func(string) bool { return true }
+	}
+	if x.logf == nil {
+		x.logf = log.Printf
+	}
+	if workers := unpackWorkers(opts.workers); workers > 1 {
+		x.jobs = make(chan func() error, workers)
+		for i := 0; i < workers; i++ {
+			x.wg.Add(1)
+			go x.writer()
+		}
+	}
+	return x
+}
+
+// writer runs jobs until there are no more, skipping them after any
+// has failed.
+func (x *extractor) writer() {
+	defer x.wg.Done()
+	for job := range x.jobs {
+		if x.failed() != nil {
+			continue
+		}
+		if err := job(); err != nil {
+			x.mu.Lock()
+			if x.err == nil {
+				x.err = err
+			}
+			x.mu.Unlock()
+		}
+	}
+}
+
+// failed returns the first error of a writer, if any.
+func (x *extractor) failed() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.err
+}
+
+// submit hands job to a writer, or runs it if there are none. It
+// returns the first error of a writer so far, if any.
+func (x *extractor) submit(job func() error) error {
+	if x.jobs == nil {
+		return job()
+	}
+	if err := x.failed(); err != nil {
+		return err
+	}
+	x.jobs <- job
+	return nil
+}
+
+// wait waits for the writers to finish and returns the first error of
+// any of them. It may be called more than once.
+func (x *extractor) wait() error {
+	if x.jobs != nil {
+		close(x.jobs)
+		x.wg.Wait()
+		x.jobs = nil
+	}
+	return x.failed()
+}
+
+// abs returns the file path of rel, after checking that no directory
+// leading to it is a symbolic link from the archive.
+func (x *extractor) abs(rel string) (string, error) {
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if _, ok := x.links[dir]; ok {
+			return "", fmt.Errorf("archive entry %s is inside symbolic link %s", rel, dir)
+		}
+	}
+	if _, ok := x.links[rel]; ok || x.files[rel] {
+		return "", fmt.Errorf("archive contains %s twice", rel)
+	}
+	return filepath.Join(x.dir, filepath.FromSlash(rel)), nil
+}
+
+// mkdirs makes the directories recorded since the last call, parents
+// first.
+func (x *extractor) mkdirs() error {
+	if len(x.newDirs) == 0 {
+		return nil
+	}
+	dirs := make([]string, 0, len(x.newDirs))
+	for dir := range x.newDirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, groupPerm(0755, x.shared)); err != nil {
+			return err
+		}
+		x.madeDir[dir] = true
+		delete(x.newDirs, dir)
+	}
+	return nil
+}
+
+// needDir records that the directory abs must be made.
+func (x *extractor) needDir(abs string) {
+	if !x.madeDir[abs] {
+		x.newDirs[abs] = true
+	}
+}
+
+// mkdir records the directory rel, to be made by mkdirs, and to be
+// given modification time mtime by finish if that is not zero.
+func (x *extractor) mkdir(rel string, mtime time.Time) error {
+	abs, err := x.abs(rel)
+	if err != nil {
+		return err
+	}
+	x.needDir(abs)
+	if !mtime.IsZero() {
+		x.mtimes = append(x.mtimes, fileTime{abs, mtime})
+	}
+	return nil
+}
+
+// file records the regular file rel, to be written by the caller once
+// mkdirs has made its directory, and to be given modification time
+// mtime by finish if that is not zero. It returns the file's path and
+// its permission bits, those of mode or 0644 if mode has none.
+func (x *extractor) file(rel string, mode os.FileMode, mtime time.Time) (abs string, perm os.FileMode, err error) {
+	abs, err = x.abs(rel)
+	if err != nil {
+		return "", 0, err
+	}
+	// Make the directory. This is redundant when the archive has a
+	// directory entry for it beforehand.
+	x.needDir(filepath.Dir(abs))
+	x.files[rel] = true
+	if !mtime.IsZero() {
+		x.mtimes = append(x.mtimes, fileTime{abs, mtime})
+	}
+	perm = mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+	return abs, groupPerm(perm, x.shared), nil
+}
+
+// openFile opens files for writeFile. Benchmarks replace it to simulate
+// file systems where creating a file is slow.
+var openFile = os.OpenFile
+
+// writeFile writes the size bytes of r to the file abs, with permission
+// bits perm.
+func writeFile(abs string, perm os.FileMode, r io.Reader, size int64) error {
+	wf, err := openFile(abs, os.O_RDWR|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(wf, io.LimitReader(r, size))
+	if closeErr := wf.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing to %s: %v", abs, err)
+	}
+	if n != size {
+		return fmt.Errorf("only wrote %d bytes to %s; expected %d", n, abs, size)
+	}
+	return nil
+}
+
+// symlink records a symbolic link from rel to target, for finish to
+// create.
+func (x *extractor) symlink(rel, target string) error {
+	if _, err := x.abs(rel); err != nil {
+		return err
+	}
+	if target == "" || strings.ContainsAny(target, "\\\x00") || path.IsAbs(target) {
+		return fmt.Errorf("symbolic link %s has invalid target %q", rel, target)
+	}
+	x.links[rel] = target
+	return nil
+}
+
+// hardlink records that rel is a hard link to the regular file target,
+// which the archive must already have written, or left in place when
+// adding files to an installed version, for finish to create.
+func (x *extractor) hardlink(rel, target string) error {
+	abs, err := x.abs(rel)
+	if err != nil {
+		return err
+	}
+	if x.skipped[target] {
+		// In an upgrade, target is skipped because it is already
+		// installed.
+		fi, err := os.Lstat(filepath.Join(x.dir, filepath.FromSlash(target)))
+		if err != nil || !fi.Mode().IsRegular() {
+			return fmt.Errorf("hard link %s refers to %s, which the install profile leaves out", rel, target)
+		}
+	} else if !x.files[target] {
+		return fmt.Errorf("hard link %s refers to %s, which is not an earlier regular file", rel, target)
+	}
+	x.needDir(filepath.Dir(abs))
+	x.files[rel] = true
+	x.hard = append(x.hard, [2]string{rel, target})
+	return nil
+}
+
+// linkFile makes rel a hard link to target, or a copy of it if the file
+// system does not support hard links.
+func (x *extractor) linkFile(rel, target string) error {
+	abs := filepath.Join(x.dir, filepath.FromSlash(rel))
+	src := filepath.Join(x.dir, filepath.FromSlash(target))
+	if err := os.Link(src, abs); err != nil {
+		fi, err := os.Stat(src)
+		if err != nil {
+			return err
+		}
+		return copyFile(abs, src, fi.Mode().Perm())
+	}
+	return nil
+}
+
+// maxLinks limits how many symbolic links resolve follows in all, as
+// the kernel does.
+const maxLinks = 40
+
+// resolve resolves the path rel, relative to the directory, through the
+// archive's symbolic links, and reports an error if it leads outside.
+// It decrements *budget for each link it follows, failing when none is
+// left, so that link targets naming other links many times over cannot
+// make it take exponential time.
+func (x *extractor) resolve(rel string, budget *int) (string, error) {
+	var cur []string
+	for _, elem := range strings.Split(rel, "/") {
+		switch elem {
+		case "", ".":
+			continue
+		case "..":
+			if len(cur) == 0 {
+				return "", errors.New("leads outside the install directory")
+			}
+			cur = cur[:len(cur)-1]
+			continue
+		}
+		cur = append(cur, elem)
+		p := strings.Join(cur, "/")
+		target, ok := x.links[p]
+		if !ok {
+			continue
+		}
+		if *budget--; *budget < 0 {
+			return "", errors.New("too many levels of symbolic links")
+		}
+		// Join without cleaning: ".." after a link leads to the
+		// parent of the link's target, not of the link.
+		resolved, err := x.resolve(path.Dir(p)+"/"+target, budget)
+		if err != nil {
+			return "", err
+		}
+		cur = nil
+		if resolved != "." {
+			cur = strings.Split(resolved, "/")
+		}
+	}
+	if len(cur) == 0 {
+		return ".", nil
+	}
+	return strings.Join(cur, "/"), nil
+}
+
+// finish waits for the writers, then creates the hard and symbolic
+// links, after checking that each symbolic link resolves to a path
+// inside the directory, and sets modification times.
+func (x *extractor) finish() error {
+	if err := x.wait(); err != nil {
+		return err
+	}
+	var names []string
+	for rel := range x.links {
+		names = append(names, rel)
+	}
+	sort.Strings(names)
+	for _, rel := range names {
+		target := x.links[rel]
+		budget := maxLinks
+		if _, err := x.resolve(path.Dir(rel)+"/"+target, &budget); err != nil {
+			return fmt.Errorf("symbolic link %s -> %s: %v", rel, target, err)
+		}
+		x.needDir(filepath.Dir(filepath.Join(x.dir, filepath.FromSlash(rel))))
+	}
+	if err := x.mkdirs(); err != nil {
+		return err
+	}
+	for _, l := range x.hard {
+		if err := x.linkFile(l[0], l[1]); err != nil {
+			return err
+		}
+	}
+	for _, rel := range names {
+		abs := filepath.Join(x.dir, filepath.FromSlash(rel))
+		if err := os.Symlink(filepath.FromSlash(x.links[rel]), abs); err != nil {
+			return err
+		}
+	}
+	// Set times last, since writing to a directory changes its time.
+	for _, t := range x.mtimes {
+		if err := os.Chtimes(t.abs, t.mtime, t.mtime); err != nil {
+			// benign error. Gerrit doesn't even set the
+			// modtime in these, and we don't end up relying
+			// on it anywhere (the gomote push command relies
+			// on digests only), so this is a little pointless
+			// for now.
+			x.logf("error changing modtime: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,424 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// An entry is an archive entry for tests.
+type entry struct {
+	name   string
+	mode   fs.FileMode // type and permission bits
+	body   string      // contents, or target of a link
+	hard   bool        // a hard link to body
+	device bool        // a character device
+}
+
+// entryTime is the modification time of entries in test archives.
+var entryTime = time.Date(2024, 7, 2, 15, 4, 5, 0, time.UTC)
+
+// tarGzEntries returns a tar.gz archive of the entries.
+func tarGzEntries(entries []entry) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: int64(e.mode.Perm()), ModTime: entryTime}
+		switch {
+		case e.hard:
+			h.Typeflag, h.Linkname = tar.TypeLink, e.body
+		case e.device:
+			h.Typeflag = tar.TypeChar
+		case e.mode&fs.ModeSymlink != 0:
+			h.Typeflag, h.Linkname = tar.TypeSymlink, e.body
+		case e.mode.IsDir():
+			h.Typeflag = tar.TypeDir
+		case e.mode&fs.ModeNamedPipe != 0:
+			h.Typeflag = tar.TypeFifo
+		default:
+			h.Typeflag, h.Size = tar.TypeReg, int64(len(e.body))
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			return nil, err
+		}
+		if h.Typeflag == tar.TypeReg {
+			tw.Write([]byte(e.body))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// zipEntries returns a zip archive of the entries.
+func zipEntries(entries []entry) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Modified: entryTime}
+		h.SetMode(e.mode)
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			return nil, err
+		}
+		w.Write([]byte(e.body))
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeEntries writes the entries to an archive in the given format in
+// dir and returns its name.
+func writeEntries(t testing.TB, dir, format string, entries []entry) string {
+	build := tarGzEntries
+	if format == ".zip" {
+		build = zipEntries
+	}
+	data, err := build(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(dir, "archive"+format)
+	if err := os.WriteFile(archive, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+// unpackEntries writes the entries to an archive in the given format,
+// which must succeed, and unpacks it to a "target" directory in a new
+// parent directory, which it returns along with the error from
+// unpacking.
+func unpackEntries(t testing.TB, format string, entries []entry) (parent string, err error) {
+	parent = t.TempDir()
+	archive := writeEntries(t, parent, format, entries)
+	target := filepath.Join(parent, "target")
+	if err := os.Mkdir(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	err = unpackArchive(context.Background(), target, archive, unpackOptions{}, quietProgress{})
+	os.Remove(archive)
+	return parent, err
+}
+
+// checkConfined reports files written outside parent/target and links
+// inside it that resolve outside it.
+func checkConfined(t testing.TB, parent string) {
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "target" {
+			t.Errorf("unpacking wrote %s outside the target directory", e.Name())
+		}
+	}
+	target := filepath.Join(parent, "target")
+	filepath.WalkDir(target, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return nil // dangling inside the target is harmless
+		}
+		realTarget, _ := filepath.EvalSymlinks(target)
+		if rel, err := filepath.Rel(realTarget, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			t.Errorf("link %s resolves outside the target directory, to %s", path, resolved)
+		}
+		return nil
+	})
+}
+
+func TestEntryPath(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"go", "."},
+		{"go/", "."},
+		{"go/go", "go"},
+		{"go/go/", "go"},
+		{"go/bin/go", "bin/go"},
+		{"go/./src//fmt", "src/fmt"},
+		{"VERSION", "VERSION"},
+	}
+	for _, tt := range tests {
+		if got, err := entryPath(tt.name); err != nil || got != tt.want {
+			t.Errorf("entryPath(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+	for _, name := range []string{"", "go//", "go/..", "/go/x", "go/../x"} {
+		if got, err := entryPath(name); err == nil {
+			t.Errorf("entryPath(%q) = %q; want error", name, got)
+		}
+	}
+}
+
+func TestUnpackMalicious(t *testing.T) {
+	sym := func(name, target string) entry { return entry{name: name, mode: fs.ModeSymlink | 0777, body: target} }
+	file := func(name string) entry { return entry{name: name, mode: 0644, body: "pwned"} }
+	tests := []struct {
+		name    string
+		entries []entry
+		formats string // archive formats to try, as extensions
+	}{
+		{"dot dot", []entry{file("go/../evil")}, ".tar.gz .zip"},
+		{"trailing dot dot", []entry{{name: "go/..", mode: fs.ModeDir | 0755}}, ".tar.gz .zip"},
+		{"absolute", []entry{file("/tmp/evil")}, ".tar.gz .zip"},
+		{"absolute after prefix", []entry{file("go//tmp/evil")}, ".tar.gz .zip"},
+		{"backslash", []entry{file(`go\..\..\evil`)}, ".tar.gz .zip"},
+		{"volume", []entry{file("C:evil")}, ".tar.gz .zip"},
+		{"symlink out", []entry{sym("go/link", "../../evil")}, ".tar.gz .zip"},
+		{"absolute symlink", []entry{sym("go/link", "/etc")}, ".tar.gz .zip"},
+		{"write through symlink", []entry{sym("go/link", "."), file("go/link/evil")}, ".tar.gz .zip"},
+		{"symlink over file", []entry{file("go/x"), sym("go/x", ".")}, ".tar.gz .zip"},
+		{"symlink chain", []entry{sym("go/b", "."), sym("go/l", "b/../../evil")}, ".tar.gz .zip"},
+		{"dot dot after symlink", []entry{sym("go/b", "."), sym("go/l", "b/../x")}, ".tar.gz .zip"},
+		{"symlink loop", []entry{sym("go/a", "b"), sym("go/b", "a"), sym("go/c", "a/x")}, ".tar.gz .zip"},
+		{"hard link out", []entry{{name: "go/h", body: "../../etc/passwd", hard: true}}, ".tar.gz"},
+		{"hard link to missing", []entry{{name: "go/h", body: "go/missing", hard: true}}, ".tar.gz"},
+		{"hard link to symlink", []entry{sym("go/s", "x"), {name: "go/h", body: "go/s", hard: true}}, ".tar.gz"},
+		{"device", []entry{{name: "go/dev", mode: 0644, device: true}}, ".tar.gz"},
+		{"fifo", []entry{{name: "go/fifo", mode: fs.ModeNamedPipe | 0644}}, ".tar.gz .zip"},
+	}
+	for _, tt := range tests {
+		for _, format := range strings.Fields(tt.formats) {
+			t.Run(tt.name+format, func(t *testing.T) {
+				parent, err := unpackEntries(t, format, tt.entries)
+				if err == nil {
+					t.Errorf("unpacking succeeded; want error")
+				}
+				checkConfined(t, parent)
+			})
+		}
+	}
+}
+
+func TestUnpackLinksAndModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links and executable bits are Unix features")
+	}
+	entries := []entry{
+		{name: "go/", mode: fs.ModeDir | 0755},
+		{name: "go/bin/go", mode: 0755, body: "binary"},
+		{name: "go/lib/time/zoneinfo.zip", mode: 0644, body: "zones"},
+		{name: "go/bin/gofmt", mode: fs.ModeSymlink | 0777, body: "go"},
+		{name: "go/misc/zoneinfo", mode: fs.ModeSymlink | 0777, body: "../lib/time/zoneinfo.zip"},
+		{name: "go/go", mode: 0600, body: "not the root"},
+	}
+	for _, format := range []string{".tar.gz", ".zip"} {
+		t.Run(format, func(t *testing.T) {
+			list := entries
+			if format == ".tar.gz" {
+				list = append(list, entry{name: "go/bin/go2", body: "go/bin/go", hard: true})
+			}
+			parent, err := unpackEntries(t, format, list)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkConfined(t, parent)
+			target := filepath.Join(parent, "target")
+			if fi, err := os.Stat(filepath.Join(target, "bin/go")); err != nil || fi.Mode().Perm()&0111 == 0 {
+				t.Errorf("bin/go mode = %v, %v; want executable", fi.Mode(), err)
+			}
+			if fi, err := os.Stat(filepath.Join(target, "lib/time/zoneinfo.zip")); err != nil || fi.Mode().Perm()&0111 != 0 {
+				t.Errorf("zoneinfo.zip mode = %v, %v; want not executable", fi.Mode(), err)
+			}
+			if fi, err := os.Stat(target); err != nil || fi.Mode() != fs.ModeDir|0755 {
+				t.Errorf("target mode = %v, %v; want %v", fi.Mode(), err, fs.ModeDir|0755)
+			}
+			if data, err := os.ReadFile(filepath.Join(target, "go")); err != nil || string(data) != "not the root" {
+				t.Errorf("reading go = %q, %v; want the file go/go", data, err)
+			}
+			for name, want := range map[string]string{"bin/gofmt": "binary", "misc/zoneinfo": "zones"} {
+				if data, err := os.ReadFile(filepath.Join(target, name)); err != nil || string(data) != want {
+					t.Errorf("reading through link %s = %q, %v; want %q", name, data, err, want)
+				}
+			}
+			if format == ".tar.gz" {
+				if data, err := os.ReadFile(filepath.Join(target, "bin/go2")); err != nil || string(data) != "binary" {
+					t.Errorf("hard link bin/go2 = %q, %v; want binary", data, err)
+				}
+			}
+		})
+	}
+}
+
+// treeEntries returns the entries of a tree of n small files in nested
+// directories, like a Go distribution, with a large binary and links.
+func treeEntries(n int) []entry {
+	entries := []entry{{name: "go/", mode: fs.ModeDir | 0755}}
+	for i := 0; i < n; i++ {
+		dir := fmt.Sprintf("go/src/pkg%d/sub%d/", i/50, i%50/10)
+		if i%10 == 0 {
+			entries = append(entries, entry{name: dir, mode: fs.ModeDir | 0755})
+		}
+		entries = append(entries, entry{name: fmt.Sprintf("%sfile%d.go", dir, i), mode: 0644, body: strings.Repeat(fmt.Sprintf("// line %d\n", i), 200)})
+	}
+	return append(entries,
+		entry{name: "go/bin/go", mode: 0755, body: strings.Repeat("binary", 2*maxBuffered/6)},
+		entry{name: "go/bin/gofmt", mode: fs.ModeSymlink | 0777, body: "go"},
+	)
+}
+
+// readTree returns a description of each file below dir: its mode, and
+// for regular files its modification time and contents, and for links
+// their target. Directories that the archive only implies have the time
+// they were made, so their times are left out.
+func readTree(t *testing.T, dir string) map[string]string {
+	tree := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fi, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+		desc := fmt.Sprint(fi.Mode())
+		switch {
+		case fi.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			desc += " -> " + target
+		case fi.Mode().IsRegular():
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			desc += fmt.Sprintf(" %v %s", fi.ModTime().Unix(), sha256Hex(data))
+		}
+		rel, _ := filepath.Rel(dir, path)
+		tree[rel] = desc
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestUnpackParallel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on Windows")
+	}
+	for _, format := range []string{".tar.gz", ".zip"} {
+		t.Run(format, func(t *testing.T) {
+			entries := treeEntries(500)
+			if format == ".tar.gz" {
+				entries = append(entries, entry{name: "go/bin/go2", body: "go/bin/go", hard: true})
+			}
+			archive := writeEntries(t, t.TempDir(), format, entries)
+			var trees []map[string]string
+			for _, workers := range []int{1, 8} {
+				target := filepath.Join(t.TempDir(), "go")
+				if err := unpackArchive(context.Background(), target, archive, unpackOptions{workers: workers}, quietProgress{}); err != nil {
+					t.Fatalf("unpacking with %d workers: %v", workers, err)
+				}
+				trees = append(trees, readTree(t, target))
+			}
+			if !reflect.DeepEqual(trees[0], trees[1]) {
+				for name, desc := range trees[0] {
+					if trees[1][name] != desc {
+						t.Errorf("%s: unpacked serially as %q and in parallel as %q", name, desc, trees[1][name])
+					}
+				}
+				t.Fatalf("unpacked trees differ")
+			}
+			tree := trees[1]
+			if len(tree) < 500 {
+				t.Errorf("unpacked %d files; want more than 500", len(tree))
+			}
+			if want := fmt.Sprint(entryTime.Unix()); !strings.Contains(tree["src/pkg3/sub2/file170.go"], " "+want+" ") {
+				t.Errorf("src/pkg3/sub2/file170.go = %q; want modification time %s", tree["src/pkg3/sub2/file170.go"], want)
+			}
+		})
+	}
+}
+
+// BenchmarkUnpack compares unpacking a tree of small files serially and
+// in parallel, on the local file system and on one where creating a file
+// takes a millisecond, as on network file systems. The gain from more
+// workers is largest there, and on local disks of machines with many
+// CPUs.
+func BenchmarkUnpack(b *testing.B) {
+	defer func() { openFile = os.OpenFile }()
+	for _, format := range []string{".tar.gz", ".zip"} {
+		archive := writeEntries(b, b.TempDir(), format, treeEntries(2000))
+		for _, latency := range []time.Duration{0, time.Millisecond} {
+			openFile = os.OpenFile
+			if latency > 0 {
+				openFile = func(name string, flag int, perm os.FileMode) (*os.File, error) {
+					time.Sleep(latency)
+					return os.OpenFile(name, flag, perm)
+				}
+			}
+			for _, workers := range []int{1, 4, 16} {
+				benchUnpack(b, fmt.Sprintf("%s/latency=%v/workers=%d", strings.TrimPrefix(format, "."), latency, workers), archive, workers)
+			}
+		}
+	}
+}
+
+func benchUnpack(b *testing.B, name, archive string, workers int) {
+	b.Run(name, func(b *testing.B) {
+		dir := b.TempDir()
+		for i := 0; i < b.N; i++ {
+			target := filepath.Join(dir, fmt.Sprint(i))
+			if err := unpackArchive(context.Background(), target, archive, unpackOptions{workers: workers}, quietProgress{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func FuzzUnpack(f *testing.F) {
+	f.Add("go/link", "../../evil", "go/link/x", false)
+	f.Add("go/b", ".", "go/l", true)
+	f.Add("go/a", "b/../..", "go/a/c", false)
+	f.Add("go/../x", "x", "go/y", false)
+	f.Add("go/bin/gofmt", "go", "go/bin/go", true)
+	f.Fuzz(func(t *testing.T, link, target, name string, zipFormat bool) {
+		if runtime.GOOS == "windows" {
+			t.Skip("symbolic links need privileges on Windows")
+		}
+		format, build := ".tar.gz", tarGzEntries
+		if zipFormat {
+			format, build = ".zip", zipEntries
+		}
+		entries := []entry{
+			{name: link, mode: fs.ModeSymlink | 0777, body: target},
+			{name: name, mode: 0644, body: "data"},
+			{name: name + "2", mode: fs.ModeSymlink | 0777, body: target + "/" + name},
+		}
+		if _, err := build(entries); err != nil {
+			t.Skip("cannot write archive:", err)
+		}
+		parent, _ := unpackEntries(t, format, entries)
+		checkConfined(t, parent)
+	})
+}
@@ -0,0 +1,191 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// probeTimeout limits how long choosing the fastest mirror waits for
+// each mirror to answer.
+const probeTimeout = 5 * time.Second
+
+// checksumSource returns the trusted source of the checksums of
+// archives downloaded from mirrors: in.ChecksumURL if set, and
+// otherwise the GODL_CHECKSUM_SOURCE setting, defaulting to the
+// official download site, so that a mirror never vouches for the
+// archives it serves. If the URL is ChecksumFromMirror, checksumSource
+// returns nil, and each archive is checked against the checksum of the
+// mirror that serves it, as private builds missing from the official
+// site must be.
+func (in *Installer) checksumSource() (source, error) {
+	rawURL := in.ChecksumURL
+	if rawURL == "" {
+		rawURL = getenv("GODL_CHECKSUM_SOURCE")
+	}
+	switch rawURL {
+	case "":
+		rawURL = DefaultBaseURL
+	case ChecksumFromMirror:
+		return nil, nil
+	}
+	return in.newSource(rawURL)
+}
+
+// ChecksumFromMirror is the checksum source, for Installer.ChecksumURL
+// and the GODL_CHECKSUM_SOURCE setting, that makes each mirror provide
+// the checksums of its own archives.
+const ChecksumFromMirror = "mirror"
+
+// orderMirrors returns mirrors in the order to try them for the named
+// archive. That is the order given, unless in.Fastest is set or the
+// GODL_MIRROR_SELECT setting is "fastest", in which case the mirrors
+// are probed concurrently and ordered by how quickly they answer, with
+// mirrors that fail to answer last.
+func (in *Installer) orderMirrors(ctx context.Context, mirrors []source, name string) []source {
+	if len(mirrors) < 2 || !in.Fastest && getenv("GODL_MIRROR_SELECT") != "fastest" {
+		return mirrors
+	}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	latency := make([]time.Duration, len(mirrors))
+	var wg sync.WaitGroup
+	for i, src := range mirrors {
+		wg.Add(1)
+		go func(i int, src source) {
+			defer wg.Done()
+			start := time.Now()
+			if _, err := src.stat(ctx, name); err != nil {
+				latency[i] = -1
+				return
+			}
+			latency[i] = time.Since(start)
+		}(i, src)
+	}
+	wg.Wait()
+
+	order := make([]int, len(mirrors))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		li, lj := latency[order[i]], latency[order[j]]
+		return li >= 0 && (lj < 0 || li < lj)
+	})
+	list := make([]source, len(mirrors))
+	for i, j := range order {
+		list[i] = mirrors[j]
+	}
+	if latency[order[0]] >= 0 {
+		in.logf("Fastest mirror: %s (%v)", list[0].url(""), latency[order[0]].Round(time.Millisecond))
+	}
+	return list
+}
+
+// failover reports whether err, from downloading an archive from a
+// mirror, means that the next mirror should be tried: the mirror
+// lacks the archive, failed to serve it, or served a corrupt one.
+func failover(err error) bool {
+	var nerr *NetworkError
+	var cerr *ChecksumError
+	return errors.Is(err, ErrNotFound) || errors.As(err, &nerr) || errors.As(err, &cerr)
+}
+
+// fetchArchive downloads the archive of version to archiveFile from the
+// first of mirrors that serves it intact, as checked against the
+// checksum from sums, or from the mirror itself if sums is nil, or
+// against in.SHA256 if set. It returns the mirror that served the
+// archive and its checksum.
+func (in *Installer) fetchArchive(ctx context.Context, version, archiveFile string, mirrors []source, sums source, p Progress) (source, string, error) {
+	base := filepath.Base(archiveFile)
+	wantSHA := in.SHA256
+	var sumErr error
+	from := sums
+	checksum := func() (string, error) {
+		if wantSHA == "" && sumErr == nil {
+			wantSHA, sumErr = from.checksum(ctx, base)
+		}
+		return wantSHA, sumErr
+	}
+
+	var lastErr error
+	var notFound []string
+	for i, src := range mirrors {
+		if sums == nil && in.SHA256 == "" {
+			from, wantSHA, sumErr = src, "", nil
+		}
+		err := in.fetchFrom(ctx, src, archiveFile, checksum, p)
+		if err == nil {
+			return src, wantSHA, nil
+		}
+		if sumErr != nil && sums != nil || ctx.Err() != nil || !failover(err) {
+			return nil, "", err
+		}
+		if errors.Is(err, ErrNotFound) {
+			notFound = append(notFound, src.url(base))
+		}
+		if i < len(mirrors)-1 {
+			in.logf("Mirror %s failed: %v; trying the next one", src.url(""), err)
+		}
+		lastErr = err
+	}
+	if len(notFound) == len(mirrors) {
+		f, _ := parseArchiveName(base)
+		return nil, "", fmt.Errorf("no binary release of %v for %v/%v at %v: %w", version, f.OS, f.Arch, strings.Join(notFound, ", "), ErrNotFound)
+	}
+	return nil, "", lastErr
+}
+
+// fetchFrom downloads the named archive from src, unless a complete
+// download is already in place, and verifies it against the checksum
+// that checksum returns. It removes an archive that fails verification.
+func (in *Installer) fetchFrom(ctx context.Context, src source, archiveFile string, checksum func() (string, error), p Progress) error {
+	base := filepath.Base(archiveFile)
+	goURL := src.url(base)
+	size, err := src.stat(ctx, base)
+	if err != nil {
+		return err
+	}
+	wantSHA, err := checksum()
+	if err != nil {
+		return err
+	}
+	if fi, err := os.Stat(archiveFile); err != nil || fi.Size() != size {
+		if err != nil && !os.IsNotExist(err) {
+			// Something weird. Don't try to download.
+			return err
+		}
+		err := in.copyFrom(ctx, archiveFile, goURL, size, func(ctx context.Context, offset, length int64) (io.ReadCloser, int64, int64, error) {
+			return src.open(ctx, base, offset, length)
+		})
+		if err != nil {
+			return fmt.Errorf("error downloading %v: %w", goURL, err)
+		}
+		fi, err = os.Stat(archiveFile)
+		if err != nil {
+			return err
+		}
+		if fi.Size() != size {
+			return &NetworkError{URL: goURL, Err: fmt.Errorf("downloaded file %s size %v doesn't match server size %v", archiveFile, fi.Size(), size)}
+		}
+	}
+	if err := verifySHA256(archiveFile, wantSHA, p); err != nil {
+		var cerr *ChecksumError
+		if errors.As(err, &cerr) {
+			os.Remove(archiveFile)
+		}
+		return fmt.Errorf("error verifying SHA256 of %v from %v: %w", archiveFile, goURL, err)
+	}
+	return nil
+}
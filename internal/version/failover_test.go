@@ -0,0 +1,120 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstallFailover(t *testing.T) {
+	name := archiveName("go1.22.0")
+	trusted := newFakeUpstream(t, []string{"go1.22.0"}, []platform{hostPlatform()})
+	trustedSrv := httptest.NewServer(trusted)
+	defer trustedSrv.Close()
+
+	// broken fails every request.
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	// corrupt serves a damaged archive, with a checksum file to match.
+	bad := newFakeUpstream(t, []string{"go1.22.0"}, []platform{hostPlatform()})
+	data := append([]byte(nil), trusted.files[name]...)
+	data[len(data)/2] ^= 0xff
+	bad.files[name] = data
+	bad.files[name+".sha256"] = []byte(sha256Hex(data))
+	corrupt := httptest.NewServer(bad)
+	defer corrupt.Close()
+
+	good := newFakeUpstream(t, []string{"go1.22.0"}, []platform{hostPlatform()})
+	good.files[name] = trusted.files[name]
+	goodSrv := httptest.NewServer(good)
+	defer goodSrv.Close()
+
+	mirrors := func(srvs ...*httptest.Server) string {
+		var urls []string
+		for _, s := range srvs {
+			urls = append(urls, s.URL+"/go/")
+		}
+		return strings.Join(urls, "|")
+	}
+	in := &Installer{
+		BaseURL:     mirrors(broken, corrupt, goodSrv),
+		ChecksumURL: trustedSrv.URL + "/go/",
+		Progress:    quietProgress{},
+		Logf:        t.Logf,
+	}
+	target := filepath.Join(t.TempDir(), "go1.22.0")
+	if err := in.Install(context.Background(), target, "go1.22.0"); err != nil {
+		t.Fatal(err)
+	}
+	r, err := ReadReceipt(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Mirror != goodSrv.URL+"/go/" || r.URL != goodSrv.URL+"/go/"+name {
+		t.Errorf("receipt records mirror %s, URL %s; want %s", r.Mirror, r.URL, goodSrv.URL+"/go/")
+	}
+	if bad.downloads[name] != 1 || good.downloads[name] != 1 || trusted.downloads[name] != 0 {
+		t.Errorf("downloads from corrupt, good, trusted = %d, %d, %d; want 1, 1, 0",
+			bad.downloads[name], good.downloads[name], trusted.downloads[name])
+	}
+
+	// With no good mirror left, the last failure is reported.
+	in.BaseURL = mirrors(broken, corrupt)
+	err = in.Install(context.Background(), filepath.Join(t.TempDir(), "go1.22.0"), "go1.22.0")
+	var cerr *ChecksumError
+	if !errors.As(err, &cerr) {
+		t.Errorf("Install from broken and corrupt mirrors = %v; want ChecksumError", err)
+	}
+
+	// A version no mirror has is not found.
+	in.BaseURL = mirrors(corrupt, goodSrv)
+	err = in.Install(context.Background(), filepath.Join(t.TempDir(), "go1.98.0"), "go1.98.0")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Install of missing version = %v; want ErrNotFound", err)
+	}
+}
+
+func TestInstallFastest(t *testing.T) {
+	name := archiveName("go1.22.0")
+	slow := newFakeUpstream(t, []string{"go1.22.0"}, []platform{hostPlatform()})
+	slowSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		slow.ServeHTTP(w, r)
+	}))
+	defer slowSrv.Close()
+	fast := newFakeUpstream(t, []string{"go1.22.0"}, []platform{hostPlatform()})
+	fast.files = slow.files
+	fastSrv := httptest.NewServer(fast)
+	defer fastSrv.Close()
+
+	in := &Installer{
+		BaseURL:  slowSrv.URL + "/go/|" + fastSrv.URL + "/go/",
+		Fastest:  true,
+		Progress: quietProgress{},
+		Logf:     t.Logf,
+	}
+	in.ChecksumURL = slowSrv.URL + "/go/"
+
+	target := filepath.Join(t.TempDir(), "go1.22.0")
+	if err := in.Install(context.Background(), target, "go1.22.0"); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := ReadReceipt(target); err != nil || r.Mirror != fastSrv.URL+"/go/" {
+		t.Errorf("receipt = %+v, %v; want mirror %s", r, err, fastSrv.URL+"/go/")
+	}
+	if slow.downloads[name] != 0 || fast.downloads[name] != 1 {
+		t.Errorf("downloads from slow, fast = %d, %d; want 0, 1", slow.downloads[name], fast.downloads[name])
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// get sends a request with the given method and extra header to url
+// using c. If in.IdleTimeout is set, the request is canceled when the
+// server sends nothing for that long, either before responding or while
+// sending the body.
+func (in *Installer) get(ctx context.Context, c *http.Client, method, url string, header http.Header) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	body := &idleTimeoutBody{timeout: in.IdleTimeout, cancel: cancel}
+	if body.timeout > 0 {
+		body.timer = time.AfterFunc(body.timeout, body.expire)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		body.stop()
+		// The NetworkError records the URL, so drop the url.Error
+		// that repeats it, possibly with credentials.
+		var uerr *neturl.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return nil, &NetworkError{URL: url, Err: body.wrap(err)}
+	}
+	body.url = url
+	body.rc = res.Body
+	res.Body = body
+	return res, nil
+}
+
+// An idleTimeoutBody is a response body that cancels its request if no
+// data arrives for timeout. It reports read errors as NetworkErrors.
+type idleTimeoutBody struct {
+	url     string
+	rc      io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer // nil if timeout is zero
+	cancel  context.CancelFunc
+	expired int32 // accessed atomically; 1 once the timer fired
+}
+
+func (b *idleTimeoutBody) expire() {
+	atomic.StoreInt32(&b.expired, 1)
+	b.cancel()
+}
+
+// wrap replaces err with a more helpful error if the timer expired.
+func (b *idleTimeoutBody) wrap(err error) error {
+	if err != nil && atomic.LoadInt32(&b.expired) == 1 {
+		return fmt.Errorf("no data received for %v: %w", b.timeout, context.DeadlineExceeded)
+	}
+	return err
+}
+
+func (b *idleTimeoutBody) stop() {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.cancel()
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	if n > 0 && b.timer != nil {
+		b.timer.Reset(b.timeout)
+	}
+	if err != nil && err != io.EOF {
+		err = &NetworkError{URL: b.url, Err: b.wrap(err)}
+	}
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.stop()
+	return b.rc.Close()
+}
+
+// minChunkSize is the smallest range that a chunked download fetches
+// in one request. It is a variable for testing.
+var minChunkSize int64 = 1 << 20
+
+// chunkAttempts is how many times a chunked download tries each range.
+const chunkAttempts = 3
+
+// errNoRanges reports that a source ignored a range request.
+var errNoRanges = errors.New("range requests not supported")
+
+// chunks returns the number of ranges to fetch the remaining bytes of
+// a download in: in.Chunks, but no more than leaves each range at least
+// minChunkSize bytes.
+func (in *Installer) chunks(remaining int64) int {
+	n := in.Chunks
+	if max := remaining / minChunkSize; int64(n) > max {
+		n = int(max)
+	}
+	return n
+}
+
+// copyChunked downloads bytes offset through size-1 of srcURL into f,
+// which holds the bytes before offset, in n ranges fetched concurrently
+// with open and written at their offsets. Each range is retried up to
+// chunkAttempts times after network errors, from where it stopped.
+//
+// If the download fails, copyChunked truncates f to the bytes
+// downloaded without gaps, so that the next attempt resumes from
+// there. If the source ignores ranges, it truncates f to offset and
+// returns errNoRanges.
+func (in *Installer) copyChunked(ctx context.Context, f *os.File, srcURL string, offset, size int64, n int, open openFunc) error {
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pw := newProgressWriter(io.Discard, in.downloadProgress(), PhaseDownload, offset, size)
+	progress := &syncWriter{w: pw}
+
+	chunk := (size - offset + int64(n) - 1) / int64(n)
+	starts := make([]int64, n)
+	ends := make([]int64, n)
+	done := make([]int64, n) // bytes of each range written
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range starts {
+		starts[i] = offset + int64(i)*chunk
+		ends[i] = starts[i] + chunk
+		if ends[i] > size {
+			ends[i] = size
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if errs[i] = in.copyChunk(ctx, f, srcURL, starts[i], ends[i], &done[i], progress, open); errs[i] != nil {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Report the first failure that was not caused by canceling the
+	// other ranges after it.
+	var err error
+	for _, e := range errs {
+		if e == errNoRanges {
+			err = e
+			break
+		}
+		if e != nil && (err == nil || errors.Is(err, context.Canceled) && !errors.Is(e, context.Canceled)) {
+			err = e
+		}
+	}
+	if err == nil {
+		pw.done()
+		return nil
+	}
+	prefix := offset
+	if err != errNoRanges {
+		for i := range starts {
+			prefix += done[i]
+			if starts[i]+done[i] < ends[i] {
+				break
+			}
+		}
+	}
+	if terr := f.Truncate(prefix); terr != nil {
+		return terr
+	}
+	if _, serr := f.Seek(prefix, io.SeekStart); serr != nil {
+		return serr
+	}
+	return err
+}
+
+// copyChunk downloads bytes start through end-1 of srcURL into f for
+// copyChunked, counting the bytes written in *done.
+func (in *Installer) copyChunk(ctx context.Context, f *os.File, srcURL string, start, end int64, done *int64, progress io.Writer, open openFunc) error {
+	var err error
+	for attempt := 0; attempt < chunkAttempts; attempt++ {
+		pos := start + *done
+		if attempt > 0 {
+			in.logf("Retrying bytes %d-%d of %s: %v", pos, end-1, srcURL, err)
+		}
+		var r io.ReadCloser
+		var got int64
+		r, got, _, err = open(ctx, pos, end-pos)
+		if err == nil && got != pos {
+			r.Close()
+			return errNoRanges
+		}
+		if err == nil {
+			var n int64
+			n, err = io.Copy(io.MultiWriter(&offsetWriter{f, pos}, progress), io.LimitReader(r, end-pos))
+			r.Close()
+			*done += n
+			if err == nil && pos+n < end {
+				err = &NetworkError{URL: srcURL, Err: io.ErrUnexpectedEOF}
+			}
+		}
+		var nerr *NetworkError
+		if err == nil || ctx.Err() != nil || !errors.As(err, &nerr) {
+			return err
+		}
+	}
+	return err
+}
+
+// An offsetWriter writes to f at successive offsets from off.
+type offsetWriter struct {
+	f   io.WriterAt
+	off int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.f.WriteAt(p, w.off)
+	w.off += int64(n)
+	return n, err
+}
+
+// A syncWriter serializes writes to w.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
@@ -0,0 +1,246 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCopyFromURLResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	dst := filepath.Join(t.TempDir(), "archive.tar.gz")
+	if err := os.WriteFile(dst+".partial", content[:4000], 0666); err != nil {
+		t.Fatal(err)
+	}
+	in := &Installer{Progress: quietProgress{}, Logf: t.Logf}
+	if err := in.copyFromURL(context.Background(), dst, srv.URL, -1); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("resumed download has %d bytes; want the %d original bytes", len(got), len(content))
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=4000-" {
+		t.Errorf("requested ranges %q; want [bytes=4000-]", ranges)
+	}
+	if _, err := os.Stat(dst + ".partial"); !os.IsNotExist(err) {
+		t.Errorf("partial file remains after download")
+	}
+}
+
+func TestCopyFromURLChunked(t *testing.T) {
+	defer func(old int64) { minChunkSize = old }(minChunkSize)
+	minChunkSize = 1000
+	content := make([]byte, 10000)
+	for i := range content {
+		content[i] = byte(i * 7)
+	}
+
+	// abort sends half of the requested range, then drops the
+	// connection.
+	abort := func(w http.ResponseWriter, r *http.Request) {
+		var start, end int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end)
+		w.Header().Set("Content-Length", fmt.Sprint(end-start+1))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(content[start : start+(end-start+1)/2])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	tests := []struct {
+		name   string
+		serve  func(w http.ResponseWriter, r *http.Request, n int) // n counts requests for the range
+		ranges []string
+	}{
+		{
+			name: "ranges",
+			serve: func(w http.ResponseWriter, r *http.Request, n int) {
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+			},
+			ranges: []string{"bytes=0-2499", "bytes=2500-4999", "bytes=5000-7499", "bytes=7500-9999"},
+		},
+		{
+			name: "retry",
+			serve: func(w http.ResponseWriter, r *http.Request, n int) {
+				if r.Header.Get("Range") == "bytes=2500-4999" && n == 1 {
+					abort(w, r)
+				}
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+			},
+			ranges: []string{"bytes=0-2499", "bytes=2500-4999", "bytes=3750-4999", "bytes=5000-7499", "bytes=7500-9999"},
+		},
+		{
+			name: "no ranges",
+			serve: func(w http.ResponseWriter, r *http.Request, n int) {
+				w.Write(content)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			requests := map[string]int{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests[r.Header.Get("Range")]++
+				n := requests[r.Header.Get("Range")]
+				mu.Unlock()
+				tt.serve(w, r, n)
+			}))
+			defer srv.Close()
+
+			dst := filepath.Join(t.TempDir(), "archive.tar.gz")
+			in := &Installer{Progress: quietProgress{}, Logf: t.Logf, Chunks: 4}
+			if err := in.copyFromURL(context.Background(), dst, srv.URL, int64(len(content))); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := os.ReadFile(dst); err != nil || !bytes.Equal(got, content) {
+				t.Errorf("chunked download has %d bytes, %v; want the %d original bytes", len(got), err, len(content))
+			}
+			if tt.ranges != nil {
+				var got []string
+				for r := range requests {
+					got = append(got, r)
+				}
+				sort.Strings(got)
+				if strings.Join(got, " ") != strings.Join(tt.ranges, " ") {
+					t.Errorf("requested ranges %q; want %q", got, tt.ranges)
+				}
+			}
+		})
+	}
+
+	// A range that keeps failing fails the download, keeping the bytes
+	// before it for the next attempt to resume.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.Header.Get("Range"), "-7499") {
+			abort(w, r)
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	dst := filepath.Join(t.TempDir(), "archive.tar.gz")
+	in := &Installer{Progress: quietProgress{}, Logf: t.Logf, Chunks: 4}
+	err := in.copyFromURL(context.Background(), dst, srv.URL, int64(len(content)))
+	srv.Close()
+	if err == nil {
+		t.Fatal("download with failing range succeeded")
+	}
+	partial, err := os.ReadFile(dst + ".partial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partial) < 5000 || len(partial) >= 7500 || !bytes.Equal(partial, content[:len(partial)]) {
+		t.Errorf("partial file has %d bytes; want a prefix through the failed range", len(partial))
+	}
+	var ranges []string
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+	in.Chunks = 1
+	if err := in.copyFromURL(context.Background(), dst, srv.URL, int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(dst); err != nil || !bytes.Equal(got, content) {
+		t.Errorf("resumed download has %d bytes, %v; want the %d original bytes", len(got), err, len(content))
+	}
+	if want := fmt.Sprintf("bytes=%d-", len(partial)); len(ranges) != 1 || ranges[0] != want {
+		t.Errorf("resume requested ranges %q; want [%s]", ranges, want)
+	}
+}
+
+func TestCopyFromURLIdleTimeout(t *testing.T) {
+	stall := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1000")
+		w.Write(make([]byte, 100))
+		w.(http.Flusher).Flush()
+		<-stall
+	}))
+	defer srv.Close()
+	defer close(stall)
+
+	dst := filepath.Join(t.TempDir(), "archive.tar.gz")
+	in := &Installer{Progress: quietProgress{}, IdleTimeout: 50 * time.Millisecond}
+	err := in.copyFromURL(context.Background(), dst, srv.URL, -1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("copyFromURL from stalled server = %v; want idle timeout", err)
+	}
+	fi, err := os.Stat(dst + ".partial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() != 100 {
+		t.Errorf("partial file has %d bytes; want 100", fi.Size())
+	}
+}
+
+func TestInstallErrors(t *testing.T) {
+	archive := []byte("not really an archive")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch filepath.Ext(r.URL.Path) {
+		case ".sha256":
+			w.Write([]byte("0000000000000000000000000000000000000000000000000000000000000000\n"))
+		case ".gz", ".zip":
+			if strings.Contains(r.URL.Path, "go1.98.") {
+				http.NotFound(w, r)
+				return
+			}
+			if strings.Contains(r.URL.Path, "go1.99.500") {
+				http.Error(w, "oops", http.StatusInternalServerError)
+				return
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(archive))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		version string
+		code    int
+	}{
+		{"go1.99.0", exitChecksum},
+		{"go1.99.500", exitNetwork},
+		{"go1.98.0", exitNotFound},
+	}
+	in := &Installer{BaseURL: srv.URL, ChecksumURL: ChecksumFromMirror, Progress: quietProgress{}, Logf: t.Logf}
+	for _, tt := range tests {
+		err := in.Install(context.Background(), filepath.Join(t.TempDir(), tt.version), tt.version)
+		if got := exitCode(err); got != tt.code {
+			t.Errorf("Install(%s) = %v, exit code %d; want exit code %d", tt.version, err, got, tt.code)
+		}
+	}
+	var cerr *ChecksumError
+	err := in.Install(context.Background(), filepath.Join(t.TempDir(), "go1.99.0"), "go1.99.0")
+	if !errors.As(err, &cerr) || cerr.Got != fmt.Sprintf("%x", sha256.Sum256(archive)) {
+		t.Errorf("Install with wrong checksum = %v; want ChecksumError with actual checksum", err)
+	}
+}
@@ -19,23 +19,24 @@ import (
 func RunTip() {
 	log.SetFlags(0)
 
-	root, err := goroot("gotip")
+	root, err := Goroot("gotip")
 	if err != nil {
-		log.Fatalf("gotip: %v", err)
+		fatal("gotip", err)
 	}
 
 	if len(os.Args) > 1 && os.Args[1] == "download" {
 		switch len(os.Args) {
 		case 2:
 			if err := installTip(root, ""); err != nil {
-				log.Fatalf("gotip: %v", err)
+				fatal("gotip", err)
 			}
 		case 3:
 			if err := installTip(root, os.Args[2]); err != nil {
-				log.Fatalf("gotip: %v", err)
+				fatal("gotip", err)
 			}
 		default:
-			log.Fatalf("gotip: usage: gotip download [CL number | branch name]")
+			log.Printf("gotip: usage: gotip download [CL number | branch name]")
+			os.Exit(2)
 		}
 		log.Printf("Success. You may now run 'gotip'!")
 		os.Exit(0)
@@ -43,7 +44,8 @@ func RunTip() {
 
 	gobin := filepath.Join(root, "bin", "go"+exe())
 	if _, err := os.Stat(gobin); err != nil {
-		log.Fatalf("gotip: not downloaded. Run 'gotip download' to install to %v", root)
+		log.Printf("gotip: not downloaded. Run 'gotip download' to install to %v", root)
+		os.Exit(exitNotInstalled)
 	}
 
 	runGo(root)
@@ -0,0 +1,254 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A lockfile pins the Go toolchains that a project uses: each version,
+// with the SHA-256 of its archive for every platform the project builds
+// on. Checked in, it makes 'dl sync' install the same toolchains on
+// every machine, verified against the pinned checksums rather than
+// against checksums fetched at install time.
+//
+// Each line of a lockfile is a version, a GOOS/GOARCH pair with GOARCH
+// spelled as in release file names, and the hex SHA-256 of the archive,
+// separated by spaces. Blank lines and lines starting with # are
+// ignored.
+
+// lockFile is the name of the lockfile, at the root of a repository.
+const lockFile = "go-toolchains.lock"
+
+// A lockEntry pins the archive of a version for a platform.
+type lockEntry struct {
+	version  string
+	platform platform
+	sum      string // hex SHA-256
+}
+
+// findLockfile returns the path of the lockfile in the current
+// directory or the nearest parent directory that has one, or in the
+// current directory if none does.
+func findLockfile() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for dir := wd; ; {
+		file := filepath.Join(dir, lockFile)
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return filepath.Join(wd, lockFile), nil
+		}
+		dir = parent
+	}
+}
+
+// lockVersion returns the version named by arg, such as go1.22.5 for
+// 1.22.5.
+func lockVersion(arg string) (string, error) {
+	v := "go" + strings.TrimPrefix(arg, "go")
+	if _, ok := parseVersion(v); !ok {
+		return "", fmt.Errorf("invalid Go version %q", arg)
+	}
+	return v, nil
+}
+
+// readLockfile reads the named lockfile.
+func readLockfile(file string) ([]lockEntry, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var list []lockEntry
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: want version, platform and SHA-256", file, n)
+		}
+		v, err := lockVersion(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", file, n, err)
+		}
+		p, err := parsePlatforms(fields[1])
+		if err != nil || len(p) != 1 {
+			return nil, fmt.Errorf("%s:%d: invalid platform %q; want GOOS/GOARCH", file, n, fields[1])
+		}
+		if len(fields[2]) != 64 || strings.Trim(fields[2], "0123456789abcdef") != "" {
+			return nil, fmt.Errorf("%s:%d: invalid SHA-256 %q", file, n, fields[2])
+		}
+		list = append(list, lockEntry{v, p[0], fields[2]})
+	}
+	return list, s.Err()
+}
+
+// writeLockfile writes list to the named lockfile, sorted by version and
+// platform.
+func writeLockfile(file string, list []lockEntry) error {
+	sort.Slice(list, func(i, j int) bool {
+		if c := cmpVersion(list[i].version, list[j].version); c != 0 {
+			return c < 0
+		}
+		return list[i].platform.String() < list[j].platform.String()
+	})
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Go toolchains installed by 'dl sync', with the SHA-256 of each archive.\n")
+	fmt.Fprintf(&b, "# Add versions and platforms with 'dl lock add'.\n")
+	for _, e := range list {
+		fmt.Fprintf(&b, "%s %s %s\n", e.version, e.platform, e.sum)
+	}
+	return os.WriteFile(file, []byte(b.String()), 0644)
+}
+
+// lockedVersions returns the versions that list pins, in order.
+func lockedVersions(list []lockEntry) []string {
+	var versions []string
+	seen := map[string]bool{}
+	for _, e := range list {
+		if !seen[e.version] {
+			seen[e.version] = true
+			versions = append(versions, e.version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return cmpVersion(versions[i], versions[j]) < 0 })
+	return versions
+}
+
+// lockedPlatforms returns the platforms that list pins archives for, in
+// order.
+func lockedPlatforms(list []lockEntry) []platform {
+	var platforms []platform
+	seen := map[platform]bool{}
+	for _, e := range list {
+		if !seen[e.platform] {
+			seen[e.platform] = true
+			platforms = append(platforms, e.platform)
+		}
+	}
+	sort.Slice(platforms, func(i, j int) bool { return platforms[i].String() < platforms[j].String() })
+	return platforms
+}
+
+// lockAdd returns list with the archives of versions for platforms
+// pinned to the checksums in the release index of src, replacing any
+// checksums that list already pins for them.
+func (in *Installer) lockAdd(ctx context.Context, src source, list []lockEntry, versions []string, platforms []platform) ([]lockEntry, error) {
+	releases, err := src.list(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing releases: %w", err)
+	}
+	byVersion := map[string]*release{}
+	for i := range releases {
+		byVersion[releases[i].Version] = &releases[i]
+	}
+	for _, v := range versions {
+		r := byVersion[v]
+		if r == nil {
+			return nil, fmt.Errorf("%s: not in the release index of %s", v, src.url(""))
+		}
+		for _, p := range platforms {
+			var file *releaseFile
+			for i, f := range r.Files {
+				if f.Kind == "archive" && f.OS == p.os && f.Arch == p.arch {
+					file = &r.Files[i]
+					break
+				}
+			}
+			if file == nil {
+				return nil, fmt.Errorf("%s: no archive for %s in the release index of %s", v, p, src.url(""))
+			}
+			sum := file.SHA256
+			if sum == "" {
+				if sum, err = src.checksum(ctx, file.Filename); err != nil {
+					return nil, fmt.Errorf("%s: %w", file.Filename, err)
+				}
+			}
+			e := lockEntry{v, p, sum}
+			replaced := false
+			for i := range list {
+				if list[i].version == v && list[i].platform == p {
+					list[i], replaced = e, true
+				}
+			}
+			if !replaced {
+				list = append(list, e)
+			}
+		}
+	}
+	return list, nil
+}
+
+// sync installs the versions that list pins, verifying each archive
+// against its pinned checksum, in the SDK root dir, or where Goroot
+// puts them if dir is empty. It fails if list pins no checksum for the
+// host platform, or if a version is already installed from an archive
+// with a different checksum.
+func (in *Installer) sync(ctx context.Context, list []lockEntry, dir string) error {
+	host := hostPlatform()
+	pinned := map[string]string{}
+	for _, e := range list {
+		if e.platform == host {
+			pinned[e.version] = e.sum
+		}
+	}
+	versions := lockedVersions(list)
+	var missing []string
+	for _, v := range versions {
+		if pinned[v] == "" {
+			missing = append(missing, v)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s pins no checksum of %s for %s; add them with 'dl lock add -platforms %s %s'",
+			lockFile, strings.Join(missing, ", "), host, host, strings.Join(missing, " "))
+	}
+
+	for _, v := range versions {
+		target := filepath.Join(dir, v)
+		if dir == "" {
+			var err error
+			if target, err = Goroot(v); err != nil {
+				return err
+			}
+		}
+		if IsInstalled(target) {
+			r, err := ReadReceipt(target)
+			if err != nil {
+				return fmt.Errorf("%s: cannot check the version installed in %s against %s: %w", v, target, lockFile, err)
+			}
+			if r.SHA256 != pinned[v] {
+				return fmt.Errorf("%s: installed in %s from an archive with SHA-256 %s, but %s pins %s; remove it and run 'dl sync' again",
+					v, target, r.SHA256, lockFile, pinned[v])
+			}
+		}
+		vin := *in
+		vin.SHA256 = pinned[v]
+		if err := vin.Install(ctx, target, v); err != nil {
+			var cerr *ChecksumError
+			if errors.As(err, &cerr) {
+				return fmt.Errorf("%s: archive does not match %s: %w", v, lockFile, err)
+			}
+			return fmt.Errorf("%s: %w", v, err)
+		}
+	}
+	return nil
+}
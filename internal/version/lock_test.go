@@ -0,0 +1,121 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLockfile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), lockFile)
+	sum := strings.Repeat("ab", 32)
+	list := []lockEntry{
+		{"go1.22.10", platform{"linux", "amd64"}, sum},
+		{"go1.22.5", platform{"linux", "armv6l"}, sum},
+		{"go1.22.5", platform{"darwin", "arm64"}, sum},
+	}
+	want := []lockEntry{list[2], list[1], list[0]}
+	if err := writeLockfile(file, list); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readLockfile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("read back %v; want %v", got, want)
+	}
+	if v := lockedVersions(got); !reflect.DeepEqual(v, []string{"go1.22.5", "go1.22.10"}) {
+		t.Errorf("lockedVersions = %v", v)
+	}
+
+	for _, line := range []string{
+		"go1.22.5 linux/amd64",
+		"tip linux/amd64 " + sum,
+		"go1.22.5 linux-amd64 " + sum,
+		"go1.22.5 linux/amd64 " + strings.Repeat("AB", 32),
+	} {
+		if err := os.WriteFile(file, []byte("# comment\n\n"+line+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readLockfile(file); err == nil || !strings.Contains(err.Error(), ":3:") {
+			t.Errorf("reading %q: %v; want error on line 3", line, err)
+		}
+	}
+}
+
+func TestLockSync(t *testing.T) {
+	other := platform{"plan9", "386"}
+	up := newFakeUpstream(t, []string{"go1.22.0", "go1.22.1"}, []platform{hostPlatform(), other})
+	srv := httptest.NewServer(up)
+	defer srv.Close()
+	in := &Installer{BaseURL: srv.URL + "/go/", Progress: quietProgress{}, Logf: t.Logf}
+	src, err := in.newSource(in.BaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	list, err := in.lockAdd(ctx, src, nil, []string{"go1.22.0", "go1.22.1"}, []platform{hostPlatform(), other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 4 {
+		t.Fatalf("lockAdd pinned %v; want 2 versions for 2 platforms", list)
+	}
+	if _, err := in.lockAdd(ctx, src, nil, []string{"go1.22.2"}, []platform{other}); err == nil {
+		t.Errorf("lockAdd of a version not in the index succeeded")
+	}
+	if _, err := in.lockAdd(ctx, src, nil, []string{"go1.22.0"}, []platform{{"aix", "ppc64"}}); err == nil {
+		t.Errorf("lockAdd for a platform without archives succeeded")
+	}
+
+	root := t.TempDir()
+	if err := in.sync(ctx, list, root); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range list {
+		if e.platform != hostPlatform() {
+			continue
+		}
+		r, err := ReadReceipt(filepath.Join(root, e.version))
+		if err != nil || r.SHA256 != e.sum {
+			t.Errorf("%s: receipt %+v, %v; want SHA-256 %s", e.version, r, err, e.sum)
+		}
+	}
+	if err := in.sync(ctx, list, root); err != nil {
+		t.Errorf("syncing again: %v", err)
+	}
+
+	// A checksum that does not match the archive fails the install.
+	bad := strings.Repeat("0", 64)
+	tampered := []lockEntry{{"go1.22.1", hostPlatform(), bad}}
+	os.RemoveAll(filepath.Join(root, "go1.22.1"))
+	var cerr *ChecksumError
+	if err := in.sync(ctx, tampered, root); !errors.As(err, &cerr) {
+		t.Errorf("sync with wrong checksum = %v; want ChecksumError", err)
+	}
+	if IsInstalled(filepath.Join(root, "go1.22.1")) {
+		t.Errorf("go1.22.1 installed despite checksum mismatch")
+	}
+
+	// So does a version installed from another archive.
+	tampered = []lockEntry{{"go1.22.0", hostPlatform(), bad}}
+	if err := in.sync(ctx, tampered, root); err == nil || !strings.Contains(err.Error(), "pins "+bad) {
+		t.Errorf("sync over mismatched install = %v; want error", err)
+	}
+
+	// And a lockfile without checksums for the host.
+	if err := in.sync(ctx, []lockEntry{{"go1.22.0", other, bad}}, root); err == nil || !strings.Contains(err.Error(), "dl lock add") {
+		t.Errorf("sync without host checksums = %v; want error", err)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package version
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// machineArch returns the GOARCH of the host. On macOS, an amd64
+// wrapper command running under Rosetta 2 sees an x86_64 machine, so
+// Apple silicon is told by the CPU features instead.
+func machineArch() string {
+	if runtime.GOOS == "darwin" {
+		if v, err := syscall.Sysctl("hw.optional.arm64"); err == nil && v != "" && v[0] == 1 {
+			return "arm64"
+		}
+	}
+	for _, name := range []string{"hw.machine_arch", "hw.machine"} {
+		if v, err := syscall.Sysctl(name); err == nil && v != "" {
+			return normalizeArch(v)
+		}
+	}
+	return ""
+}
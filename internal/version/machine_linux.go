@@ -0,0 +1,29 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import "syscall"
+
+// machineArch returns the GOARCH of the host: that of /bin/sh, which
+// tells a 32-bit userland on a 64-bit kernel and sees through emulation
+// of the wrapper command, or else that of the machine the kernel
+// reports.
+func machineArch() string {
+	if arch := elfArch("/bin/sh"); arch != "" {
+		return arch
+	}
+	var u syscall.Utsname
+	if err := syscall.Uname(&u); err != nil {
+		return ""
+	}
+	var b []byte
+	for _, c := range u.Machine {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	return normalizeArch(string(b))
+}
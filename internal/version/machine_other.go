@@ -0,0 +1,14 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux && !windows && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package version
+
+// machineArch returns the GOARCH of the host, or "" if it cannot be
+// told, as on this platform, in which case that of the wrapper command
+// is used.
+func machineArch() string {
+	return ""
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var isWow64Process2 = syscall.NewLazyDLL("kernel32.dll").NewProc("IsWow64Process2")
+
+// Machine types of IsWow64Process2.
+var imageFileMachines = map[uint16]string{
+	0x014c: "386",
+	0x01c4: "arm",
+	0x8664: "amd64",
+	0xaa64: "arm64",
+}
+
+// machineArch returns the GOARCH of the host: the native machine that
+// IsWow64Process2 reports, which sees through both WOW64 and x64
+// emulation on ARM64, or on older Windows the processor architecture
+// that WOW64 records in the environment.
+func machineArch() string {
+	if isWow64Process2.Find() == nil {
+		var process, native uint16
+		h, _ := syscall.GetCurrentProcess()
+		r, _, _ := isWow64Process2.Call(uintptr(h), uintptr(unsafe.Pointer(&process)), uintptr(unsafe.Pointer(&native)))
+		if arch := imageFileMachines[native]; r != 0 && arch != "" {
+			return arch
+		}
+	}
+	for _, key := range []string{"PROCESSOR_ARCHITEW6432", "PROCESSOR_ARCHITECTURE"} {
+		if v := os.Getenv(key); v != "" {
+			return normalizeArch(v)
+		}
+	}
+	return ""
+}
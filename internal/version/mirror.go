@@ -0,0 +1,197 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A platform is a GOOS/GOARCH pair, with GOARCH spelled as in release
+// file names.
+type platform struct {
+	os, arch string
+}
+
+// parsePlatforms parses a comma-separated list of GOOS/GOARCH pairs.
+func parsePlatforms(s string) ([]platform, error) {
+	var list []platform
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		goos, goarch, ok := strings.Cut(p, "/")
+		if !ok || goos == "" || goarch == "" {
+			return nil, fmt.Errorf("invalid platform %q; want GOOS/GOARCH", p)
+		}
+		list = append(list, platform{goos, releaseArch(goos, goarch)})
+	}
+	return list, nil
+}
+
+// releaseArch returns the architecture name used in release file names
+// for goarch on goos.
+func releaseArch(goos, goarch string) string {
+	if goos == "linux" && goarch == "arm" {
+		return "armv6l"
+	}
+	return goarch
+}
+
+// A mirrorConfig selects the release archives to mirror.
+type mirrorConfig struct {
+	versions  versionFilter
+	platforms []platform // all platforms if empty
+	unstable  bool       // include betas and release candidates
+	verify    bool       // re-verify archives already in the mirror
+}
+
+// selectFiles returns the archives of releases that c selects.
+func (c *mirrorConfig) selectFiles(releases []release) []releaseFile {
+	var files []releaseFile
+	for _, r := range releases {
+		if !r.Stable && !c.unstable || !c.versions.match(r.Version) {
+			continue
+		}
+		for _, f := range r.Files {
+			if f.Kind != "archive" || !c.matchPlatform(f) {
+				continue
+			}
+			if f.Version == "" {
+				f.Version = r.Version
+			}
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+func (c *mirrorConfig) matchPlatform(f releaseFile) bool {
+	if len(c.platforms) == 0 {
+		return true
+	}
+	for _, p := range c.platforms {
+		if p.os == f.OS && p.arch == f.Arch {
+			return true
+		}
+	}
+	return false
+}
+
+// mirror copies the release archives that c selects from src to the
+// directory out, laid out like the official download site: each archive
+// next to a .sha256 file holding its checksum, and a release index in
+// indexFile. Archives already in out are kept, so that repeated runs
+// only download what is missing. Every archive is verified against the
+// checksum in the release index of src.
+func (in *Installer) mirror(ctx context.Context, src source, out string, c *mirrorConfig) error {
+	releases, err := src.list(ctx)
+	if err != nil {
+		return fmt.Errorf("listing releases: %w", err)
+	}
+	files := c.selectFiles(releases)
+	if len(files) == 0 {
+		return fmt.Errorf("no release archives match")
+	}
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return err
+	}
+
+	var fetched, kept int
+	for i := range files {
+		f := &files[i]
+		if f.SHA256 == "" {
+			if f.SHA256, err = src.checksum(ctx, f.Filename); err != nil {
+				return fmt.Errorf("%s: %w", f.Filename, err)
+			}
+		}
+		dst := filepath.Join(out, f.Filename)
+		if c.mirrored(dst, f) {
+			kept++
+			continue
+		}
+		in.logf("Downloading %s ...", f.Filename)
+		size := f.Size
+		if size == 0 {
+			size = -1
+		}
+		err := in.copyFrom(ctx, dst, src.url(f.Filename), size, func(ctx context.Context, offset, length int64) (io.ReadCloser, int64, int64, error) {
+			return src.open(ctx, f.Filename, offset, length)
+		})
+		if err != nil {
+			return fmt.Errorf("downloading %s: %w", f.Filename, err)
+		}
+		if err := verifySHA256(dst, f.SHA256, in.progress()); err != nil {
+			os.Remove(dst)
+			return err
+		}
+		if err := os.WriteFile(dst+".sha256", []byte(f.SHA256), 0644); err != nil {
+			return err
+		}
+		fetched++
+	}
+	if err := updateIndex(filepath.Join(out, indexFile), files); err != nil {
+		return err
+	}
+	in.logf("Mirrored %d archives to %s (%d downloaded, %d already present)", len(files), out, fetched, kept)
+	return nil
+}
+
+// mirrored reports whether dst already holds the archive f. Unless
+// c.verify is set, it trusts an archive of the right size whose
+// checksum file matches.
+func (c *mirrorConfig) mirrored(dst string, f *releaseFile) bool {
+	fi, err := os.Stat(dst)
+	if err != nil || f.Size > 0 && fi.Size() != f.Size {
+		return false
+	}
+	sum, err := os.ReadFile(dst + ".sha256")
+	if err != nil || strings.TrimSpace(string(sum)) != f.SHA256 {
+		return false
+	}
+	return !c.verify || verifySHA256(dst, f.SHA256, quietProgress{}) == nil
+}
+
+// updateIndex adds files to the release index in the named file,
+// creating it if needed.
+func updateIndex(file string, files []releaseFile) error {
+	byName := map[string]releaseFile{}
+	if data, err := os.ReadFile(file); err == nil {
+		var old []release
+		if err := json.Unmarshal(data, &old); err != nil {
+			return fmt.Errorf("parsing %s: %v", file, err)
+		}
+		for _, r := range old {
+			for _, f := range r.Files {
+				byName[f.Filename] = f
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	for _, f := range files {
+		byName[f.Filename] = f
+	}
+	var all []releaseFile
+	for _, f := range byName {
+		all = append(all, f)
+	}
+	data, err := json.MarshalIndent(groupReleases(all), "", " ")
+	if err != nil {
+		return err
+	}
+	// Write the index atomically, so a server never serves half of it.
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
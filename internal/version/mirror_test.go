@@ -0,0 +1,170 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUpstream serves archives and a release index laid out like the
+// official download site, counting archive downloads.
+type fakeUpstream struct {
+	files    map[string][]byte // by file name
+	releases []release
+
+	mu        sync.Mutex
+	downloads map[string]int
+}
+
+// newFakeUpstream returns a fakeUpstream with an archive of each
+// version for each platform.
+func newFakeUpstream(t *testing.T, versions []string, platforms []platform) *fakeUpstream {
+	u := &fakeUpstream{files: map[string][]byte{}, downloads: map[string]int{}}
+	var files []releaseFile
+	for _, v := range versions {
+		for _, p := range platforms {
+			ext := ".tar.gz"
+			if p.os == "windows" {
+				ext = ".zip"
+			}
+			name := v + "." + p.os + "-" + p.arch + ext
+			data := testArchive(t, map[string]string{"VERSION": v})
+			u.files[name] = data
+			u.files[name+".sha256"] = []byte(sha256Hex(data) + "\n")
+			files = append(files, releaseFile{
+				Filename: name, OS: p.os, Arch: p.arch, Version: v,
+				SHA256: sha256Hex(data), Size: int64(len(data)), Kind: "archive",
+			})
+		}
+	}
+	u.releases = groupReleases(files)
+	return u
+}
+
+func (u *fakeUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/go/")
+	if name == indexFile {
+		json.NewEncoder(w).Encode(u.releases)
+		return
+	}
+	data, ok := u.files[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method == "GET" && !strings.HasSuffix(name, ".sha256") {
+		u.mu.Lock()
+		u.downloads[name]++
+		u.mu.Unlock()
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+func TestMirror(t *testing.T) {
+	linux, darwin := platform{"linux", "amd64"}, platform{"darwin", "arm64"}
+	up := newFakeUpstream(t, []string{"go1.20.14", "go1.21.0", "go1.22rc1", "go1.22.0"}, []platform{linux, darwin, {"windows", "amd64"}})
+	srv := httptest.NewServer(up)
+	defer srv.Close()
+
+	in := &Installer{Progress: quietProgress{}, Logf: t.Logf}
+	src, err := in.newSource(srv.URL + "/go/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions, _ := parseVersionFilter(">=1.21")
+	c := &mirrorConfig{versions: versions, platforms: []platform{linux, darwin}}
+	out := t.TempDir()
+	ctx := context.Background()
+	if err := in.mirror(ctx, src, out, c); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"go1.21.0.darwin-arm64.tar.gz", "go1.21.0.linux-amd64.tar.gz",
+		"go1.22.0.darwin-arm64.tar.gz", "go1.22.0.linux-amd64.tar.gz",
+	}
+	for _, name := range want {
+		data, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil || !bytes.Equal(data, up.files[name]) {
+			t.Errorf("mirror has %s = %d bytes, %v; want upstream's", name, len(data), err)
+		}
+		if sum, err := os.ReadFile(filepath.Join(out, name+".sha256")); err != nil || string(sum) != sha256Hex(up.files[name]) {
+			t.Errorf("mirror has %s.sha256 = %q, %v", name, sum, err)
+		}
+		if up.downloads[name] != 1 {
+			t.Errorf("downloaded %s %d times; want once", name, up.downloads[name])
+		}
+	}
+	entries, _ := os.ReadDir(out)
+	if len(entries) != 2*len(want)+1 {
+		t.Errorf("mirror has %d files; want %d archives, their checksums and the index", len(entries), len(want))
+	}
+
+	// A second run, also including release candidates, only downloads
+	// what is missing.
+	c.unstable = true
+	if err := in.mirror(ctx, src, out, c); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range want {
+		if up.downloads[name] != 1 {
+			t.Errorf("after second run, downloaded %s %d times; want once", name, up.downloads[name])
+		}
+	}
+	if up.downloads["go1.22rc1.linux-amd64.tar.gz"] != 1 {
+		t.Errorf("second run did not download go1.22rc1")
+	}
+
+	// The mirror is itself a source that can be installed from.
+	mirrorSrv := httptest.NewServer(http.StripPrefix("/go/", http.FileServer(http.Dir(out))))
+	defer mirrorSrv.Close()
+	msrc, err := in.newSource(mirrorSrv.URL + "/go/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := msrc.list(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[0].Version != "go1.22.0" || list[1].Version != "go1.22rc1" || list[1].Stable {
+		t.Errorf("mirror index = %+v; want go1.22.0, go1.22rc1 (unstable) and go1.21.0", list)
+	}
+}
+
+func TestMirrorChecksumMismatch(t *testing.T) {
+	linux := platform{"linux", "amd64"}
+	up := newFakeUpstream(t, []string{"go1.22.0"}, []platform{linux})
+	name := "go1.22.0.linux-amd64.tar.gz"
+	up.files[name] = append(up.files[name][:len(up.files[name]):len(up.files[name])], 0)
+	up.releases[0].Files[0].Size++
+	srv := httptest.NewServer(up)
+	defer srv.Close()
+
+	in := &Installer{Progress: quietProgress{}, Logf: t.Logf}
+	src, err := in.newSource(srv.URL + "/go/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := t.TempDir()
+	err = in.mirror(context.Background(), src, out, &mirrorConfig{})
+	var cerr *ChecksumError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("mirror of corrupt archive = %v; want ChecksumError", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, name)); !os.IsNotExist(err) {
+		t.Errorf("corrupt archive left in mirror")
+	}
+}
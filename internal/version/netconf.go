@@ -0,0 +1,376 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// newTransport returns a transport set up as net/http sets up
+// http.DefaultTransport. The installer leaves http.DefaultTransport
+// alone, since programs embedding it may have replaced it with their
+// own.
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// A netConfig configures the HTTP clients of an Installer that has no
+// Client of its own. Its fields hold settings:
+//
+//   - GODL_CA_BUNDLE: a file of PEM certificates to trust in addition
+//     to the system's;
+//   - GODL_CLIENT_CERT and GODL_CLIENT_KEY: PEM files holding a client
+//     certificate and its key, for servers that require mutual TLS;
+//     the key defaults to the certificate file;
+//   - GODL_PROXY: the URL of a proxy for all requests, overriding
+//     HTTPS_PROXY and HTTP_PROXY;
+//   - GODL_AUTH: comma-separated host=value pairs, where value is sent
+//     as the Authorization header to that host, such as
+//     "artifacts.example.com=Bearer TOKEN";
+//   - GODL_NETRC: a netrc file of logins for servers and proxies,
+//     defaulting to $NETRC and then ~/.netrc. Only machine entries are
+//     used; as in the go command, the default entry is ignored.
+//
+// Credentials are only sent to servers over HTTPS.
+type netConfig struct {
+	caBundle   string
+	clientCert string
+	clientKey  string
+	proxy      string
+	auth       string
+	netrc      string
+}
+
+// netConfigSetting returns the netConfig set by the settings.
+func netConfigSetting() netConfig {
+	c := netConfig{
+		caBundle:   getenv("GODL_CA_BUNDLE"),
+		clientCert: getenv("GODL_CLIENT_CERT"),
+		clientKey:  getenv("GODL_CLIENT_KEY"),
+		proxy:      getenv("GODL_PROXY"),
+		auth:       getenv("GODL_AUTH"),
+		netrc:      getenv("GODL_NETRC"),
+	}
+	if c.netrc == "" {
+		c.netrc = os.Getenv("NETRC")
+	}
+	if home, err := homedir(); c.netrc == "" && err == nil {
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+		c.netrc = filepath.Join(home, name)
+	}
+	return c
+}
+
+// A clientKey identifies a cached client.
+type clientKey struct {
+	netConfig
+	archive bool
+}
+
+var (
+	clientsMu sync.Mutex
+	clients   = map[clientKey]*http.Client{}
+)
+
+// httpClient returns the client configured by c, for downloading
+// archives if archive is set and for small files otherwise. Clients are
+// cached, so that connections are reused. If c is invalid, the client
+// fails every request with the reason.
+func httpClient(c netConfig, archive bool) *http.Client {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	key := clientKey{c, archive}
+	if client := clients[key]; client != nil {
+		return client
+	}
+	var rt http.RoundTripper
+	t, err := c.transport()
+	if err != nil {
+		rt = errTransport{err}
+	} else {
+		if archive {
+			// It's already compressed. Prefer accurate ContentLength.
+			// (Not that GCS would try to compress it, though)
+			t.DisableCompression = true
+			t.DisableKeepAlives = true
+		}
+		rt = &authTransport{rt: t, auth: c.authHeaders(), logins: c.logins()}
+	}
+	client := &http.Client{Transport: &userAgentTransport{rt}}
+	clients[key] = client
+	return client
+}
+
+// transport returns a transport with the TLS and proxy settings of c.
+func (c netConfig) transport() (*http.Transport, error) {
+	t := newTransport()
+	if c.caBundle != "" || c.clientCert != "" {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	if c.caBundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(c.caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("GODL_CA_BUNDLE: %v", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("GODL_CA_BUNDLE: no certificates found in %s", c.caBundle)
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	if c.clientCert != "" {
+		key := c.clientKey
+		if key == "" {
+			key = c.clientCert
+		}
+		cert, err := tls.LoadX509KeyPair(c.clientCert, key)
+		if err != nil {
+			return nil, fmt.Errorf("GODL_CLIENT_CERT: %v", err)
+		}
+		t.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+	proxy, err := c.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	t.Proxy = proxy
+	return t, nil
+}
+
+// proxyFunc returns the proxy function of c: GODL_PROXY if set, and
+// otherwise the proxy configured by the environment. A proxy URL
+// without credentials gets them from the netrc file, if it has a
+// login for the proxy host.
+func (c netConfig) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	logins := c.logins()
+	withLogin := func(u *url.URL) *url.URL {
+		if u == nil || u.User != nil {
+			return u
+		}
+		if l, ok := lookupLogin(logins, u.Hostname()); ok {
+			u2 := *u
+			u2.User = url.UserPassword(l.login, l.password)
+			return &u2
+		}
+		return u
+	}
+	if c.proxy != "" {
+		u, err := parseURL(c.proxy)
+		if err != nil {
+			return nil, fmt.Errorf("GODL_PROXY: invalid proxy URL %s: %v", redactURL(c.proxy), err)
+		}
+		if u.Host == "" {
+			return nil, fmt.Errorf("GODL_PROXY: invalid proxy URL %s: missing host", redactURL(c.proxy))
+		}
+		u = withLogin(u)
+		return http.ProxyURL(u), nil
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := http.ProxyFromEnvironment(req)
+		return withLogin(u), err
+	}, nil
+}
+
+// authHeaders parses the GODL_AUTH setting of c into Authorization
+// headers by host.
+func (c netConfig) authHeaders() map[string]string {
+	auth := map[string]string{}
+	for _, entry := range strings.Split(c.auth, ",") {
+		host, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if ok && host != "" && value != "" {
+			auth[strings.ToLower(host)] = value
+		}
+	}
+	return auth
+}
+
+// logins returns the logins in the netrc file of c, if any.
+func (c netConfig) logins() []netrcLogin {
+	data, err := os.ReadFile(c.netrc)
+	if err != nil {
+		return nil
+	}
+	return parseNetrc(string(data))
+}
+
+// An authTransport adds credentials for the request host to HTTPS
+// requests that carry none: the Authorization header configured for the
+// host, or else its netrc login.
+type authTransport struct {
+	rt     http.RoundTripper
+	auth   map[string]string // by lower-case host name
+	logins []netrcLogin
+}
+
+func (t *authTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.URL.Scheme != "https" || r.Header.Get("Authorization") != "" || r.URL.User != nil {
+		return t.rt.RoundTrip(r)
+	}
+	host := strings.ToLower(r.URL.Hostname())
+	if value, ok := t.auth[host]; ok {
+		r = r.Clone(r.Context())
+		r.Header.Set("Authorization", value)
+	} else if l, ok := lookupLogin(t.logins, host); ok {
+		r = r.Clone(r.Context())
+		r.SetBasicAuth(l.login, l.password)
+	}
+	return t.rt.RoundTrip(r)
+}
+
+// An errTransport fails every request with err.
+type errTransport struct {
+	err error
+}
+
+func (t errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+// A netrcLogin is a login from a netrc file.
+type netrcLogin struct {
+	machine  string
+	login    string
+	password string
+}
+
+// parseNetrc parses the machine logins in the netrc file data. As in
+// the go command, the default login and anything after it are ignored,
+// so that its credentials are never sent to whatever host is asked.
+func parseNetrc(data string) []netrcLogin {
+	var logins []netrcLogin
+	cur := -1 // index in logins of the entry being parsed
+	inMacro := false
+Lines:
+	for _, line := range strings.Split(data, "\n") {
+		if inMacro {
+			// A macro definition runs to the next empty line.
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		f := strings.Fields(line)
+	Tokens:
+		for i := 0; i < len(f); i++ {
+			switch f[i] {
+			case "default":
+				// The default login must come last.
+				break Lines
+			case "machine":
+				if i+1 < len(f) {
+					logins = append(logins, netrcLogin{machine: f[i+1]})
+					cur = len(logins) - 1
+				}
+				i++
+			case "login", "password":
+				if i+1 < len(f) && cur >= 0 {
+					if f[i] == "login" {
+						logins[cur].login = f[i+1]
+					} else {
+						logins[cur].password = f[i+1]
+					}
+				}
+				i++
+			case "account":
+				i++
+			case "macdef":
+				inMacro = true
+				break Tokens
+			}
+		}
+	}
+	complete := logins[:0]
+	for _, l := range logins {
+		if l.password != "" {
+			complete = append(complete, l)
+		}
+	}
+	return complete
+}
+
+// lookupLogin returns the login of the machine entry for host in
+// logins.
+func lookupLogin(logins []netrcLogin, host string) (netrcLogin, bool) {
+	for _, l := range logins {
+		if strings.EqualFold(l.machine, host) {
+			return l, true
+		}
+	}
+	return netrcLogin{}, false
+}
+
+// parseURL parses rawURL as url.Parse does, but leaves rawURL, which
+// may hold credentials, out of the error, including the parts of it
+// that url.Parse quotes for bad escapes and host names.
+func parseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	var uerr *url.Error
+	if !errors.As(err, &uerr) {
+		return u, err
+	}
+	switch uerr.Err.(type) {
+	case url.EscapeError:
+		return nil, errors.New("invalid URL escape")
+	case url.InvalidHostError:
+		return nil, errors.New("invalid character in host name")
+	}
+	return nil, uerr.Err
+}
+
+// redactURL returns rawURL with any credentials in it replaced by
+// "xxxxx", for messages. If rawURL does not parse as a URL with user
+// information, any is stripped from it as text: everything between
+// "//", or the start if there is none, and the last "@" before the path.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		start := 0
+		if i := strings.Index(rawURL, "//"); i >= 0 {
+			start = i + len("//")
+		}
+		end := len(rawURL)
+		if i := strings.IndexAny(rawURL[start:], "/?#"); i >= 0 {
+			end = start + i
+		}
+		if i := strings.LastIndex(rawURL[start:end], "@"); i >= 0 {
+			return rawURL[:start] + "xxxxx" + rawURL[start+i:]
+		}
+		return rawURL
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	} else {
+		// A user name alone is often a token.
+		u.User = url.User("xxxxx")
+	}
+	return u.String()
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"debug/elf"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Releases are installed for the platform of the host, which is not
+// always that of the wrapper command: an amd64 wrapper may run under
+// emulation on an arm64 machine, and a 386 wrapper on a 64-bit kernel.
+// The host's architecture is therefore detected, as the architecture of
+// the programs it runs natively where that can be told (the ELF header
+// of /bin/sh on Linux, and whether the CPU is Apple silicon on macOS),
+// and otherwise as the machine the kernel reports. The GODL_GOOS and
+// GODL_GOARCH settings override the detected platform; set to the empty
+// string in the environment, they restore it over the config file.
+//
+// Old releases lack archives for some platforms, such as darwin/arm64
+// before Go 1.16. For those, the archive of a platform whose programs
+// the host runs too is installed instead, as archFallbacks lists,
+// unless GODL_GOARCH is set.
+
+var (
+	machineOnce sync.Once
+	machine     string // GOARCH of the host, or "" if unknown
+)
+
+func (p platform) String() string { return p.os + "/" + p.arch }
+
+// archive returns the file name of the zip or tar.gz archive of the
+// given Go version for p.
+func (p platform) archive(version string) string {
+	ext := ".tar.gz"
+	if p.os == "windows" {
+		ext = ".zip"
+	}
+	return version + "." + p.os + "-" + p.arch + ext
+}
+
+// hostPlatform returns the platform to install releases for.
+func hostPlatform() platform {
+	machineOnce.Do(func() { machine = machineArch() })
+	goos, goarch := getOS(), machine
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	if v := getenv("GODL_GOOS"); v != "" {
+		goos = v
+	}
+	if v := getenv("GODL_GOARCH"); v != "" {
+		goarch = normalizeArch(v)
+	}
+	return platform{goos, releaseArch(goos, goarch)}
+}
+
+// archFallbacks maps platforms to platforms whose programs they also
+// run, for installing releases that have no archive for the first.
+var archFallbacks = map[platform]platform{
+	{"darwin", "arm64"}:  {"darwin", "amd64"},  // under Rosetta 2
+	{"windows", "arm64"}: {"windows", "amd64"}, // under x64 emulation, Windows 11 and later
+	{"windows", "amd64"}: {"windows", "386"},   // under WOW64
+	{"linux", "amd64"}:   {"linux", "386"},     // with 32-bit support in the kernel
+	{"freebsd", "amd64"}: {"freebsd", "386"},
+}
+
+// installPlatforms returns the platforms whose archives to try to
+// install, in order: the host's, followed by its fallbacks unless
+// GODL_GOARCH sets the architecture.
+func installPlatforms() []platform {
+	if getenv("GODL_GOARCH") != "" {
+		return []platform{hostPlatform()}
+	}
+	return fallbackPlatforms(hostPlatform())
+}
+
+// fallbackPlatforms returns p followed by the platforms that
+// archFallbacks lists for it, in turn.
+func fallbackPlatforms(p platform) []platform {
+	list := []platform{p}
+	for {
+		next, ok := archFallbacks[list[len(list)-1]]
+		if !ok {
+			return list
+		}
+		list = append(list, next)
+	}
+}
+
+// normalizeArch returns the GOARCH for an architecture named as uname,
+// Windows or the go command name it, such as amd64 for x86_64 or AMD64.
+func normalizeArch(arch string) string {
+	arch = strings.ToLower(arch)
+	switch {
+	case arch == "x86_64" || arch == "x64":
+		return "amd64"
+	case arch == "x86" || arch == "i386" || arch == "i486" || arch == "i586" || arch == "i686":
+		return "386"
+	case arch == "aarch64" || arch == "arm64":
+		return "arm64"
+	case strings.HasPrefix(arch, "arm"):
+		// armv6l, armv7l, and armv8l for 32-bit programs on an
+		// arm64 kernel, all of which run the armv6l archives.
+		return "arm"
+	case arch == "loongarch64":
+		return "loong64"
+	case arch == "mips64el":
+		return "mips64le"
+	case arch == "mipsel":
+		return "mipsle"
+	}
+	return arch
+}
+
+// elfArch returns the GOARCH of the named ELF executable, or "" if it
+// cannot be read or is for an architecture Go does not support.
+func elfArch(name string) string {
+	f, err := elf.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	is64, le := f.Class == elf.ELFCLASS64, f.Data == elf.ELFDATA2LSB
+	switch f.Machine {
+	case elf.EM_X86_64:
+		return "amd64"
+	case elf.EM_386:
+		return "386"
+	case elf.EM_AARCH64:
+		return "arm64"
+	case elf.EM_ARM:
+		return "arm"
+	case elf.EM_PPC64:
+		if le {
+			return "ppc64le"
+		}
+		return "ppc64"
+	case elf.EM_S390:
+		if is64 {
+			return "s390x"
+		}
+	case elf.EM_RISCV:
+		if is64 {
+			return "riscv64"
+		}
+	case elf.EM_MIPS:
+		arch := "mips"
+		if is64 {
+			arch = "mips64"
+		}
+		if le {
+			arch += "le"
+		}
+		return arch
+	case 258: // EM_LOONGARCH
+		return "loong64"
+	}
+	return ""
+}
@@ -0,0 +1,230 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bytes"
+	"context"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeArch(t *testing.T) {
+	tests := map[string]string{
+		"x86_64":      "amd64",
+		"AMD64":       "amd64",
+		"x64":         "amd64",
+		"i686":        "386",
+		"x86":         "386",
+		"aarch64":     "arm64",
+		"ARM64":       "arm64",
+		"armv7l":      "arm",
+		"armv8l":      "arm",
+		"arm":         "arm",
+		"loongarch64": "loong64",
+		"mips64el":    "mips64le",
+		"mipsel":      "mipsle",
+		"ppc64le":     "ppc64le",
+		"s390x":       "s390x",
+	}
+	for arch, want := range tests {
+		if got := normalizeArch(arch); got != want {
+			t.Errorf("normalizeArch(%q) = %q; want %q", arch, got, want)
+		}
+	}
+}
+
+// elfHeader returns the file header of an ELF executable for the given
+// machine.
+func elfHeader(t *testing.T, class elf.Class, data elf.Data, machine elf.Machine) []byte {
+	t.Helper()
+	var order binary.ByteOrder = binary.LittleEndian
+	if data == elf.ELFDATA2MSB {
+		order = binary.BigEndian
+	}
+	var ident [elf.EI_NIDENT]byte
+	copy(ident[:], elf.ELFMAG)
+	ident[elf.EI_CLASS], ident[elf.EI_DATA], ident[elf.EI_VERSION] = byte(class), byte(data), byte(elf.EV_CURRENT)
+	var hdr any
+	if class == elf.ELFCLASS64 {
+		hdr = &elf.Header64{Ident: ident, Type: uint16(elf.ET_EXEC), Machine: uint16(machine), Version: uint32(elf.EV_CURRENT), Ehsize: 64}
+	} else {
+		hdr = &elf.Header32{Ident: ident, Type: uint16(elf.ET_EXEC), Machine: uint16(machine), Version: uint32(elf.EV_CURRENT), Ehsize: 52}
+	}
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, order, hdr); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestElfArch(t *testing.T) {
+	tests := []struct {
+		class   elf.Class
+		data    elf.Data
+		machine elf.Machine
+		want    string
+	}{
+		{elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, "amd64"},
+		{elf.ELFCLASS32, elf.ELFDATA2LSB, elf.EM_386, "386"},
+		{elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_AARCH64, "arm64"},
+		{elf.ELFCLASS32, elf.ELFDATA2LSB, elf.EM_ARM, "arm"},
+		{elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_PPC64, "ppc64le"},
+		{elf.ELFCLASS64, elf.ELFDATA2MSB, elf.EM_PPC64, "ppc64"},
+		{elf.ELFCLASS64, elf.ELFDATA2MSB, elf.EM_S390, "s390x"},
+		{elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_RISCV, "riscv64"},
+		{elf.ELFCLASS32, elf.ELFDATA2LSB, elf.EM_RISCV, ""},
+		{elf.ELFCLASS32, elf.ELFDATA2MSB, elf.EM_MIPS, "mips"},
+		{elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_MIPS, "mips64le"},
+		{elf.ELFCLASS64, elf.ELFDATA2LSB, 258, "loong64"},
+		{elf.ELFCLASS32, elf.ELFDATA2LSB, elf.EM_SPARC, ""},
+	}
+	dir := t.TempDir()
+	for i, tt := range tests {
+		name := filepath.Join(dir, fmt.Sprint(i))
+		if err := os.WriteFile(name, elfHeader(t, tt.class, tt.data, tt.machine), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if got := elfArch(name); got != tt.want {
+			t.Errorf("elfArch of %v %v %v = %q; want %q", tt.class, tt.data, tt.machine, got, tt.want)
+		}
+	}
+
+	name := filepath.Join(dir, "script")
+	if err := os.WriteFile(name, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := elfArch(name); got != "" {
+		t.Errorf("elfArch of a script = %q; want \"\"", got)
+	}
+}
+
+func TestHostPlatformSettings(t *testing.T) {
+	t.Setenv("GODL_GOOS", "linux")
+	t.Setenv("GODL_GOARCH", "armv7l")
+	if got, want := hostPlatform(), (platform{"linux", "armv6l"}); got != want {
+		t.Errorf("hostPlatform() = %v; want %v", got, want)
+	}
+	if got, want := archiveName("go1.99.1"), "go1.99.1.linux-armv6l.tar.gz"; got != want {
+		t.Errorf("archiveName = %q; want %q", got, want)
+	}
+
+	// Setting the architecture leaves out the fallbacks.
+	t.Setenv("GODL_GOOS", "windows")
+	t.Setenv("GODL_GOARCH", "AMD64")
+	if got, want := installPlatforms(), []platform{{"windows", "amd64"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("installPlatforms() = %v; want %v", got, want)
+	}
+	if got, want := archiveName("go1.99.1"), "go1.99.1.windows-amd64.zip"; got != want {
+		t.Errorf("archiveName = %q; want %q", got, want)
+	}
+}
+
+func TestHostPlatformEmptySetting(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GODL_CONFIG", filepath.Join(dir, "no-such-config"))
+	t.Setenv("GODL_GOOS", "")
+	t.Setenv("GODL_GOARCH", "")
+	os.Unsetenv("GODL_GOOS")
+	os.Unsetenv("GODL_GOARCH")
+	detected, platforms := hostPlatform(), installPlatforms()
+
+	// Variables set to the empty string in the environment override
+	// the config file, restoring the detected platform.
+	config := filepath.Join(dir, "config")
+	if err := os.WriteFile(config, []byte("GODL_GOOS=plan9\nGODL_GOARCH=mips\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GODL_CONFIG", config)
+	if got, want := hostPlatform(), (platform{"plan9", "mips"}); got != want {
+		t.Errorf("hostPlatform() with config file = %v; want %v", got, want)
+	}
+	t.Setenv("GODL_GOOS", "")
+	t.Setenv("GODL_GOARCH", "")
+	if got := hostPlatform(); got != detected {
+		t.Errorf("hostPlatform() with empty GODL_GOOS and GODL_GOARCH = %v; want detected %v", got, detected)
+	}
+	if got := installPlatforms(); !reflect.DeepEqual(got, platforms) {
+		t.Errorf("installPlatforms() with empty GODL_GOARCH = %v; want %v", got, platforms)
+	}
+}
+
+func TestFallbackPlatforms(t *testing.T) {
+	tests := []struct {
+		p    platform
+		want []platform
+	}{
+		{platform{"darwin", "arm64"}, []platform{{"darwin", "arm64"}, {"darwin", "amd64"}}},
+		{platform{"windows", "arm64"}, []platform{{"windows", "arm64"}, {"windows", "amd64"}, {"windows", "386"}}},
+		{platform{"linux", "amd64"}, []platform{{"linux", "amd64"}, {"linux", "386"}}},
+		{platform{"linux", "arm64"}, []platform{{"linux", "arm64"}}},
+	}
+	for _, tt := range tests {
+		if got := fallbackPlatforms(tt.p); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("fallbackPlatforms(%v) = %v; want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestInstallFallback(t *testing.T) {
+	t.Setenv("GODL_GOOS", "")
+	t.Setenv("GODL_GOARCH", "")
+	platforms := installPlatforms()
+	if len(platforms) < 2 {
+		t.Skipf("%v has no fallback platform", platforms[0])
+	}
+	fallback := platforms[len(platforms)-1]
+
+	// The source has only the archive of the last fallback.
+	dir := t.TempDir()
+	archive := testArchive(t, map[string]string{"VERSION": "go1.99.1"})
+	name := fallback.archive("go1.99.1")
+	if err := os.WriteFile(filepath.Join(dir, name), archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".sha256"), []byte(sha256Hex(archive)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var log strings.Builder
+	in := &Installer{BaseURL: fileURL(dir), ChecksumURL: ChecksumFromMirror, Progress: quietProgress{}, Logf: func(format string, args ...any) {
+		fmt.Fprintf(&log, format+"\n", args...)
+	}}
+	target := filepath.Join(t.TempDir(), "go1.99.1")
+	if err := in.Install(context.Background(), target, "go1.99.1"); err != nil {
+		t.Fatal(err)
+	}
+	r, err := ReadReceipt(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Platform != fallback.String() || !strings.HasSuffix(r.URL, "/"+name) {
+		t.Errorf("installed %s for %s; want %s for %s", r.URL, r.Platform, name, fallback)
+	}
+	want := fmt.Sprintf("no binary release for %s; trying the one for %s", platforms[0], platforms[1])
+	if !strings.Contains(log.String(), want) {
+		t.Errorf("log lacks %q:\n%s", want, log.String())
+	}
+
+	// Nor is there with a pinned checksum, which only the host's
+	// archive could match.
+	pinned := *in
+	pinned.SHA256 = sha256Hex(archive)
+	if err := pinned.Install(context.Background(), filepath.Join(t.TempDir(), "go1.99.1"), "go1.99.1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Install with pinned checksum = %v; want ErrNotFound", err)
+	}
+
+	// With the architecture set, there is no fallback.
+	t.Setenv("GODL_GOARCH", platforms[0].arch)
+	if err := in.Install(context.Background(), filepath.Join(t.TempDir(), "go1.99.1"), "go1.99.1"); err == nil || !errors.Is(err, ErrNotFound) {
+		t.Errorf("Install with GODL_GOARCH=%s = %v; want ErrNotFound", platforms[0].arch, err)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// A Profile selects the files of a distribution to install, by
+// slash-separated glob patterns matched against paths relative to the
+// install directory. A pattern that matches a directory matches
+// everything in it, and a "**" element matches any number of path
+// elements.
+type Profile struct {
+	// Name is "full" or empty for every file, or "minimal" to leave
+	// out the files that building programs does not need: tests, test
+	// data, documentation, API lists and miscellaneous tools.
+	Name string `json:"name"`
+
+	// Include, if not empty, limits the install to files matching one
+	// of these patterns.
+	Include []string `json:"include,omitempty"`
+
+	// Exclude leaves out files matching any of these patterns, in
+	// addition to those the named profile leaves out.
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// ProfileNames lists the profile names.
+var ProfileNames = []string{"full", "minimal"}
+
+// minimalExclude lists the patterns that the minimal profile leaves
+// out.
+var minimalExclude = []string{"api", "doc", "misc", "test", "src/**/testdata"}
+
+// profileSetting returns the Profile set by the GODL_PROFILE,
+// GODL_INCLUDE and GODL_EXCLUDE settings.
+func profileSetting() Profile {
+	return Profile{
+		Name:    getenv("GODL_PROFILE"),
+		Include: splitPatterns(getenv("GODL_INCLUDE")),
+		Exclude: splitPatterns(getenv("GODL_EXCLUDE")),
+	}
+}
+
+// splitPatterns splits a comma-separated list of patterns.
+func splitPatterns(s string) []string {
+	var list []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// full reports whether pr installs every file.
+func (pr Profile) full() bool {
+	return (pr.Name == "" || pr.Name == "full") && len(pr.Include) == 0 && len(pr.Exclude) == 0
+}
+
+// String returns the name of pr, with any patterns.
+func (pr Profile) String() string {
+	s := pr.Name
+	if s == "" {
+		s = "full"
+	}
+	if len(pr.Include) > 0 {
+		s += " include=" + strings.Join(pr.Include, ",")
+	}
+	if len(pr.Exclude) > 0 {
+		s += " exclude=" + strings.Join(pr.Exclude, ",")
+	}
+	return s
+}
+
+// equal reports whether pr and other select the same files.
+func (pr Profile) equal(other Profile) bool {
+	return pr.String() == other.String()
+}
+
+// A fileFilter decides which archive entries to unpack, by their paths
+// as returned by entryPath.
+type fileFilter func(rel string) bool
+
+// filter returns the filter that selects the files of pr, or an error
+// if pr is invalid.
+func (pr Profile) filter() (fileFilter, error) {
+	exclude := pr.Exclude
+	switch pr.Name {
+	case "", "full":
+	case "minimal":
+		exclude = append(minimalExclude[:len(minimalExclude):len(minimalExclude)], exclude...)
+	default:
+		return nil, fmt.Errorf("unknown profile %q; want one of %s", pr.Name, strings.Join(ProfileNames, ", "))
+	}
+	for _, p := range append(pr.Include[:len(pr.Include):len(pr.Include)], exclude...) {
+		if _, err := path.Match(p, ""); err != nil || p == "" || path.IsAbs(p) {
+			return nil, fmt.Errorf("invalid file pattern %q", p)
+		}
+	}
+	include := pr.Include
+	return func(rel string) bool {
+		if rel == "." {
+			return true
+		}
+		if len(include) > 0 && !matchAny(include, rel) {
+			return false
+		}
+		return !matchAny(exclude, rel)
+	}, nil
+}
+
+// matchAny reports whether any of patterns matches rel.
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if matchPattern(strings.Split(path.Clean(p), "/"), strings.Split(rel, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern reports whether the pattern elements pat match the path
+// elements name or a prefix of them.
+func matchPattern(pat, name []string) bool {
+	if len(pat) == 0 {
+		return true
+	}
+	if pat[0] == "**" {
+		return matchPattern(pat[1:], name) || len(name) > 0 && matchPattern(pat, name[1:])
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pat[0], name[0])
+	return ok && matchPattern(pat[1:], name[1:])
+}
+
+// upgradeFilter returns the filter selecting the files that profile pr
+// adds to a version installed with profile old, or nil if pr adds none.
+// An installed version can only be upgraded in place to every file.
+func (pr Profile) upgradeFilter(old Profile) (fileFilter, error) {
+	if old.full() || pr.equal(old) {
+		return nil, nil
+	}
+	if !pr.full() {
+		return nil, fmt.Errorf("installed with profile %v; remove it or install the full profile to change that", old)
+	}
+	had, err := old.filter()
+	if err != nil {
+		return nil, err
+	}
+	return func(rel string) bool { return !had(rel) }, nil
+}
@@ -0,0 +1,265 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestProfileFilter(t *testing.T) {
+	tests := []struct {
+		profile Profile
+		keep    string // space-separated paths
+		drop    string
+	}{
+		{Profile{}, ". test/a.go doc/go_spec.html src/fmt/testdata/x", ""},
+		{
+			Profile{Name: "minimal"},
+			". bin/go src/fmt/print.go src/testing/testing.go lib/time/zoneinfo.zip pkg/tool/linux_amd64/compile",
+			"test test/fixedbugs/issue1.go doc/go_spec.html misc/wasm/wasm_exec.js api/go1.txt src/fmt/testdata/x src/cmd/go/testdata/script/a.txt",
+		},
+		{
+			Profile{Include: []string{"bin", "src/**/*.go"}, Exclude: []string{"**/*_test.go"}},
+			". bin/go src/fmt/print.go src/cmd/go/main.go",
+			"pkg/tool/linux_amd64/compile src/fmt/print_test.go src/fmt/testdata/x.txt",
+		},
+		{Profile{Exclude: []string{"src/**"}}, "bin/go srcx/a", "src src/fmt/print.go"},
+	}
+	for _, tt := range tests {
+		keep, err := tt.profile.filter()
+		if err != nil {
+			t.Fatalf("%v: %v", tt.profile, err)
+		}
+		for _, rel := range strings.Fields(tt.keep) {
+			if !keep(rel) {
+				t.Errorf("profile %v leaves out %s; want it kept", tt.profile, rel)
+			}
+		}
+		for _, rel := range strings.Fields(tt.drop) {
+			if keep(rel) {
+				t.Errorf("profile %v keeps %s; want it left out", tt.profile, rel)
+			}
+		}
+	}
+
+	for _, pr := range []Profile{{Name: "tiny"}, {Exclude: []string{"src/["}}, {Include: []string{"/bin"}}} {
+		if _, err := pr.filter(); err == nil {
+			t.Errorf("profile %v is valid; want error", pr)
+		}
+	}
+}
+
+// profileArchive writes an archive of a fake Go release with the files
+// of a distribution that profiles select between to a file source, and
+// returns the URL of the source. Its go command runs the given shell
+// script, which is run with GOROOT set to the installed version.
+func profileArchive(t *testing.T, version, script string) string {
+	dir := t.TempDir()
+	archive := testArchive(t, map[string]string{
+		"VERSION":                  version,
+		"bin/go":                   "#!/bin/sh\n" + script + "\n",
+		"src/fmt/print.go":         "package fmt",
+		"src/fmt/testdata/x.txt":   "test data",
+		"test/fixedbugs/bug1.go":   "package main",
+		"doc/go_spec.html":         "spec",
+		"lib/time/zoneinfo.zip":    "zones",
+		"misc/wasm/wasm_exec.js":   "js",
+		"api/go1.txt":              "api",
+		"src/cmd/go/testdata/x.go": "package x",
+	})
+	name := archiveName(version)
+	if err := os.WriteFile(filepath.Join(dir, name), archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".sha256"), []byte(sha256Hex(archive)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fileURL(dir)
+}
+
+func TestInstallProfile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake go command is a shell script")
+	}
+	in := &Installer{BaseURL: profileArchive(t, "go1.99.1", "exit 0"), ChecksumURL: ChecksumFromMirror, Progress: quietProgress{}, Logf: t.Logf, Profile: Profile{Name: "minimal"}}
+	target := filepath.Join(t.TempDir(), "go1.99.1")
+	if err := in.Install(context.Background(), target, "go1.99.1"); err != nil {
+		t.Fatal(err)
+	}
+	exists := func(rel string) bool {
+		_, err := os.Stat(filepath.Join(target, rel))
+		return err == nil
+	}
+	for _, rel := range []string{"VERSION", "bin/go", "src/fmt/print.go", "lib/time/zoneinfo.zip"} {
+		if !exists(rel) {
+			t.Errorf("minimal install lacks %s", rel)
+		}
+	}
+	left := []string{"src/fmt/testdata/x.txt", "test", "doc", "misc", "api", "src/cmd/go/testdata"}
+	for _, rel := range left {
+		if exists(rel) {
+			t.Errorf("minimal install has %s", rel)
+		}
+	}
+	r, err := ReadReceipt(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Profile == nil || r.Profile.Name != "minimal" {
+		t.Errorf("receipt profile = %v; want minimal", r.Profile)
+	}
+
+	// Another minimal install does nothing; a custom one is refused.
+	if err := in.Install(context.Background(), target, "go1.99.1"); err != nil {
+		t.Errorf("installing minimal profile again: %v", err)
+	}
+	in.Profile = Profile{Exclude: []string{"doc"}}
+	if err := in.Install(context.Background(), target, "go1.99.1"); err == nil {
+		t.Errorf("installing profile %v over minimal succeeded; want error", in.Profile)
+	}
+
+	// A full install adds the files left out, in place.
+	os.WriteFile(filepath.Join(target, "src/fmt/local.go"), []byte("kept"), 0644)
+	in.Profile = Profile{}
+	if err := in.Install(context.Background(), target, "go1.99.1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range append(left, "src/fmt/local.go") {
+		if !exists(rel) {
+			t.Errorf("after upgrade, install lacks %s", rel)
+		}
+	}
+	if r, err := ReadReceipt(target); err != nil || r.Profile != nil {
+		t.Errorf("after upgrade, receipt profile = %v, %v; want none", r.Profile, err)
+	}
+	if !IsInstalled(target) {
+		t.Errorf("after upgrade, version is not installed")
+	}
+}
+
+func TestInstallProfileBroken(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake go command is a shell script")
+	}
+	in := &Installer{BaseURL: profileArchive(t, "go1.99.1", "exit 1"), ChecksumURL: ChecksumFromMirror, Progress: quietProgress{}, Logf: t.Logf, Profile: Profile{Name: "minimal"}}
+	target := filepath.Join(t.TempDir(), "go1.99.1")
+	err := in.Install(context.Background(), target, "go1.99.1")
+	if err == nil || !strings.Contains(err.Error(), "smoke build") {
+		t.Errorf("Install = %v; want smoke build failure", err)
+	}
+	if IsInstalled(target) {
+		t.Errorf("version is installed after failed smoke build")
+	}
+	if _, err := os.Stat(filepath.Join(target, "bin")); err == nil {
+		t.Errorf("unpacked files left after failed smoke build")
+	}
+}
+
+func TestInstallProfileUpgradeBroken(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake go command is a shell script")
+	}
+	// The go command fails once the files the minimal profile leaves
+	// out are installed.
+	in := &Installer{
+		BaseURL:     profileArchive(t, "go1.99.1", `test ! -e "$GOROOT/doc"`),
+		ChecksumURL: ChecksumFromMirror,
+		Progress:    quietProgress{},
+		Logf:        t.Logf,
+		Profile:     Profile{Name: "minimal"},
+		ReadOnly:    true,
+	}
+	target := filepath.Join(t.TempDir(), "go1.99.1")
+	if err := in.Install(context.Background(), target, "go1.99.1"); err != nil {
+		t.Fatal(err)
+	}
+	in.Profile, in.SmokeTest = Profile{}, "version"
+	err := in.Install(context.Background(), target, "go1.99.1")
+	if err == nil || !strings.Contains(err.Error(), "smoke test") {
+		t.Fatalf("upgrading Install = %v; want smoke test failure", err)
+	}
+
+	// The minimal install is left as it was, and still runs.
+	if !IsInstalled(target) {
+		t.Errorf("version is not installed after failed upgrade")
+	}
+	for _, rel := range []string{"doc", "test", "misc", "api", "src/fmt/testdata"} {
+		if _, err := os.Stat(filepath.Join(target, rel)); err == nil {
+			t.Errorf("failed upgrade left %s", rel)
+		}
+	}
+	for _, rel := range []string{"VERSION", "bin/go", "src/fmt/print.go"} {
+		if _, err := os.Stat(filepath.Join(target, rel)); err != nil {
+			t.Errorf("failed upgrade removed %s", rel)
+		}
+	}
+	if r, err := ReadReceipt(target); err != nil || r.Profile == nil || r.Profile.Name != "minimal" {
+		t.Errorf("after failed upgrade, receipt = %+v, %v; want profile minimal", r, err)
+	}
+	if fi, err := os.Stat(filepath.Join(target, "bin")); err == nil && fi.Mode().Perm()&0222 != 0 {
+		t.Errorf("after failed upgrade, bin has mode %v; want read-only", fi.Mode())
+	}
+	if err := GoCommand(context.Background(), target, "version").Run(); err != nil {
+		t.Errorf("after failed upgrade, go command fails: %v", err)
+	}
+}
+
+func TestInstallProfileUpgradeHardLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("zip archives have no hard links")
+	}
+	// A file that the minimal profile leaves out is a hard link to one
+	// that it installs.
+	archive, err := tarGzEntries([]entry{
+		{name: "go/VERSION", mode: 0644, body: "go1.99.1"},
+		{name: "go/bin/go", mode: 0755, body: "#!/bin/sh\nexit 0\n"},
+		{name: "go/src/fmt/print.go", mode: 0644, body: "package fmt"},
+		{name: "go/test/fixedbugs/print.go", hard: true, body: "go/src/fmt/print.go"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	name := archiveName("go1.99.1")
+	if err := os.WriteFile(filepath.Join(dir, name), archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".sha256"), []byte(sha256Hex(archive)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	in := &Installer{
+		BaseURL:     fileURL(dir),
+		ChecksumURL: ChecksumFromMirror,
+		Progress:    quietProgress{},
+		Logf:        t.Logf,
+		Profile:     Profile{Name: "minimal"},
+		ReadOnly:    true,
+	}
+	target := filepath.Join(t.TempDir(), "go1.99.1")
+	t.Cleanup(func() { makeWritable(target) })
+	if err := in.Install(context.Background(), target, "go1.99.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(target, "test")); err == nil {
+		t.Fatalf("minimal install has test")
+	}
+
+	// Upgrading adds the link, to the file already installed.
+	in.Profile = Profile{}
+	if err := in.Install(context.Background(), target, "go1.99.1"); err != nil {
+		t.Fatalf("upgrading Install: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(target, "test/fixedbugs/print.go")); err != nil || string(data) != "package fmt" {
+		t.Errorf("after upgrade, test/fixedbugs/print.go = %q, %v; want %q", data, err, "package fmt")
+	}
+	if !IsInstalled(target) {
+		t.Errorf("version is not installed after upgrade")
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Phase is a step of an install that reports progress.
+type Phase string
+
+const (
+	PhaseDownload Phase = "download" // downloading the archive
+	PhaseVerify   Phase = "verify"   // checking the archive's SHA-256
+	PhaseUnpack   Phase = "unpack"   // unpacking the archive
+)
+
+// A ProgressEvent reports how much of a phase is done. All amounts are
+// in bytes of the archive, except when unpacking a zip archive, when
+// they are bytes of the unpacked files. The unpacked size of a tar.gz
+// archive is not known until it is all decompressed, so its progress
+// is that of reading the compressed archive.
+type ProgressEvent struct {
+	Phase Phase `json:"phase"`
+	Done  int64 `json:"done"`
+	Total int64 `json:"total"` // -1 if unknown
+	Final bool  `json:"final"` // whether this is the last event of the phase
+
+	// RateLimit is the limit on the download rate in bytes per second,
+	// or 0 if there is none.
+	RateLimit int64 `json:"rateLimit,omitempty"`
+}
+
+// A Progress receives the progress of an install. Report is called
+// frequently and is expected to throttle its output.
+type Progress interface {
+	Report(ProgressEvent)
+}
+
+// ProgressModes lists the modes accepted by NewProgress.
+var ProgressModes = []string{"auto", "tty", "lines", "ci", "json", "quiet"}
+
+// NewProgress returns a Progress that writes to w in the given mode:
+//
+//   - "tty": a bar redrawn in place, with rate and remaining time;
+//   - "lines": a line per second, as the wrapper commands always did;
+//   - "ci": a line each time another step percent is done;
+//   - "json": newline-delimited JSON ProgressEvents;
+//   - "quiet": nothing;
+//   - "auto" or "": "tty" if w is a terminal, "ci" if the CI
+//     environment variable is set, and "lines" otherwise.
+func NewProgress(mode string, w io.Writer, step int) (Progress, error) {
+	if step <= 0 || step > 100 {
+		step = 10
+	}
+	if mode == "" || mode == "auto" {
+		switch {
+		case isTerminal(w):
+			mode = "tty"
+		case os.Getenv("CI") != "":
+			mode = "ci"
+		default:
+			mode = "lines"
+		}
+	}
+	switch mode {
+	case "tty":
+		return &barProgress{output: w}, nil
+	case "lines":
+		return &linesProgress{output: w}, nil
+	case "ci":
+		return &ciProgress{output: w, step: step}, nil
+	case "json":
+		return &jsonProgress{enc: json.NewEncoder(w)}, nil
+	case "quiet":
+		return quietProgress{}, nil
+	}
+	return nil, fmt.Errorf("unknown progress mode %q; want one of %s", mode, strings.Join(ProgressModes, ", "))
+}
+
+// progressSetting returns the Progress configured by the GODL_PROGRESS
+// and GODL_PROGRESS_STEP settings, writing to standard error. It falls
+// back to "auto" if the setting is invalid.
+func progressSetting() Progress {
+	step, _ := strconv.Atoi(getenv("GODL_PROGRESS_STEP"))
+	p, err := NewProgress(getenv("GODL_PROGRESS"), os.Stderr, step)
+	if err != nil {
+		p, _ = NewProgress("auto", os.Stderr, step)
+	}
+	return p
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func percent(done, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return 100 * float64(done) / float64(total)
+}
+
+var phaseVerbs = map[Phase]string{
+	PhaseDownload: "Downloaded",
+	PhaseVerify:   "Verified",
+	PhaseUnpack:   "Unpacked",
+}
+
+// A progressWriter reports the bytes written through it as progress of
+// phase.
+type progressWriter struct {
+	w     io.Writer
+	p     Progress
+	phase Phase
+	n     int64
+	total int64
+}
+
+// newProgressWriter returns a progressWriter writing to w, starting
+// phase with done of total bytes already done.
+func newProgressWriter(w io.Writer, p Progress, phase Phase, done, total int64) *progressWriter {
+	pw := &progressWriter{w: w, p: p, phase: phase, n: done, total: total}
+	p.Report(ProgressEvent{Phase: phase, Done: done, Total: total})
+	return pw
+}
+
+func (pw *progressWriter) Write(buf []byte) (n int, err error) {
+	n, err = pw.w.Write(buf)
+	pw.n += int64(n)
+	pw.p.Report(ProgressEvent{Phase: pw.phase, Done: pw.n, Total: pw.total})
+	return
+}
+
+// done reports the end of the phase.
+func (pw *progressWriter) done() {
+	pw.p.Report(ProgressEvent{Phase: pw.phase, Done: pw.n, Total: pw.total, Final: true})
+}
+
+// linesProgress prints a line at most once a second.
+type linesProgress struct {
+	output    io.Writer
+	formatted bool // print sizes in units rather than bytes
+	last      time.Time
+}
+
+func (p *linesProgress) Report(ev ProgressEvent) {
+	if now := time.Now(); ev.Final || now.Unix() != p.last.Unix() {
+		p.update(ev)
+		p.last = now
+	}
+}
+
+func (p *linesProgress) update(ev ProgressEvent) {
+	end := " ..."
+	if ev.Done == ev.Total {
+		end = ""
+	}
+	end = rateLimitNote(ev) + end
+	if p.formatted {
+		fmt.Fprintf(p.output, "%s %5.1f%% (%s / %s)%s\n", phaseVerbs[ev.Phase],
+			percent(ev.Done, ev.Total),
+			fmtSize(ev.Done), fmtSize(ev.Total), end)
+	} else {
+		fmt.Fprintf(p.output, "%s %5.1f%% (%*d / %d bytes)%s\n", phaseVerbs[ev.Phase],
+			percent(ev.Done, ev.Total),
+			ndigits(ev.Total), ev.Done, ev.Total, end)
+	}
+}
+
+// rateLimitNote describes the rate limit of ev, if any, for appending
+// to a progress line.
+func rateLimitNote(ev ProgressEvent) string {
+	if ev.RateLimit <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [limited to %s/s]", fmtSize(ev.RateLimit))
+}
+
+func ndigits(i int64) int {
+	var n int
+	for ; i != 0; i /= 10 {
+		n++
+	}
+	return n
+}
+
+// ciProgress prints a line each time another step percent is done.
+type ciProgress struct {
+	output io.Writer
+	step   int
+	phase  Phase
+	next   int // next percentage to print
+}
+
+func (p *ciProgress) Report(ev ProgressEvent) {
+	if ev.Phase != p.phase {
+		p.phase, p.next = ev.Phase, 0
+	}
+	pct := int(percent(ev.Done, ev.Total))
+	if !ev.Final && (ev.Total <= 0 || pct < p.next) {
+		return
+	}
+	if ev.Final {
+		pct = 100
+	}
+	fmt.Fprintf(p.output, "%s %3d%% (%s)%s\n", phaseVerbs[ev.Phase], pct, fmtSize(ev.Done), rateLimitNote(ev))
+	p.next = (pct/p.step + 1) * p.step
+}
+
+// barProgress draws a bar in place on a terminal.
+type barProgress struct {
+	output io.Writer
+	phase  Phase
+	start  time.Time
+	last   time.Time
+}
+
+const barWidth = 30
+
+func (p *barProgress) Report(ev ProgressEvent) {
+	now := time.Now()
+	if ev.Phase != p.phase {
+		p.phase, p.start = ev.Phase, now
+	}
+	if !ev.Final && now.Sub(p.last) < 100*time.Millisecond {
+		return
+	}
+	p.last = now
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\r%-10s ", phaseVerbs[ev.Phase])
+	if ev.Total > 0 {
+		filled := int(barWidth * ev.Done / ev.Total)
+		fmt.Fprintf(&b, "[%s%s] %5.1f%% ", strings.Repeat("=", filled), strings.Repeat(" ", barWidth-filled), percent(ev.Done, ev.Total))
+	}
+	fmt.Fprintf(&b, "%s", fmtSize(ev.Done))
+	if elapsed := now.Sub(p.start).Seconds(); elapsed > 0 && ev.Done > 0 {
+		rate := float64(ev.Done) / elapsed
+		fmt.Fprintf(&b, " %s/s%s", fmtSize(int64(rate)), rateLimitNote(ev))
+		if ev.Total > 0 && !ev.Final {
+			eta := time.Duration(float64(ev.Total-ev.Done) / rate * float64(time.Second))
+			fmt.Fprintf(&b, " ETA %v", eta.Round(time.Second))
+		}
+	}
+	// Clear what is left of a longer previous line.
+	b.WriteString("\033[K")
+	if ev.Final {
+		b.WriteString("\n")
+	}
+	io.WriteString(p.output, b.String())
+}
+
+// jsonProgress writes an event for the start and end of each phase and
+// for each further percent done.
+type jsonProgress struct {
+	enc   *json.Encoder
+	phase Phase
+	last  int // last percentage written, or -1
+}
+
+func (p *jsonProgress) Report(ev ProgressEvent) {
+	if ev.Phase != p.phase {
+		p.phase, p.last = ev.Phase, -1
+	}
+	pct := int(percent(ev.Done, ev.Total))
+	if !ev.Final && pct == p.last {
+		return
+	}
+	p.last = pct
+	p.enc.Encode(ev)
+}
+
+type quietProgress struct{}
+
+func (quietProgress) Report(ProgressEvent) {}
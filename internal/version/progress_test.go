@@ -0,0 +1,133 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCIProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p, err := NewProgress("ci", &buf, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for done := int64(0); done < 100; done += 5 {
+		p.Report(ProgressEvent{Phase: PhaseDownload, Done: done, Total: 100})
+	}
+	p.Report(ProgressEvent{Phase: PhaseDownload, Done: 100, Total: 100, Final: true})
+	want := []string{"  0%", " 25%", " 50%", " 75%", "100%"}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got lines:\n%s\nwant %d lines", buf.String(), len(want))
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "Downloaded") || !strings.Contains(line, want[i]) {
+			t.Errorf("line %d = %q; want Downloaded %s", i, line, want[i])
+		}
+	}
+}
+
+func TestJSONProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p, err := NewProgress("json", &buf, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw := newProgressWriter(io.Discard, p, PhaseUnpack, 0, 1000)
+	for i := 0; i < 100; i++ {
+		pw.Write(make([]byte, 10))
+	}
+	pw.done()
+
+	dec := json.NewDecoder(&buf)
+	var events []ProgressEvent
+	for {
+		var ev ProgressEvent
+		if err := dec.Decode(&ev); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, ev)
+	}
+	// One event at the start, one per percent and the final one.
+	if len(events) != 102 {
+		t.Errorf("got %d events; want 102", len(events))
+	}
+	last := events[len(events)-1]
+	if want := (ProgressEvent{Phase: PhaseUnpack, Done: 1000, Total: 1000, Final: true}); last != want {
+		t.Errorf("last event = %+v; want %+v", last, want)
+	}
+}
+
+func TestBarProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p, err := NewProgress("tty", &buf, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Report(ProgressEvent{Phase: PhaseDownload, Done: 0, Total: 1000})
+	// Redraws are throttled, except for the last one.
+	p.Report(ProgressEvent{Phase: PhaseDownload, Done: 500, Total: 1000})
+	p.Report(ProgressEvent{Phase: PhaseDownload, Done: 1000, Total: 1000, Final: true})
+
+	draws := strings.SplitAfter(buf.String(), "\033[K")
+	if len(draws) != 3 || draws[2] != "\n" {
+		t.Fatalf("drew %q; want two bars and a newline", buf.String())
+	}
+	if want := "\rDownloaded [" + strings.Repeat(" ", barWidth) + "]   0.0% "; !strings.HasPrefix(draws[0], want) {
+		t.Errorf("first bar = %q; want prefix %q", draws[0], want)
+	}
+	if want := "\rDownloaded [" + strings.Repeat("=", barWidth) + "] 100.0% "; !strings.HasPrefix(draws[1], want) || strings.Contains(draws[1], "ETA") {
+		t.Errorf("last bar = %q; want prefix %q and no ETA", draws[1], want)
+	}
+
+	// Without a total, only the amount done is drawn.
+	buf.Reset()
+	p.Report(ProgressEvent{Phase: PhaseUnpack, Done: 2048, Total: -1, Final: true})
+	if got := buf.String(); got != "\rUnpacked   2 KB\033[K\n" {
+		t.Errorf("bar without total = %q; want amount only", got)
+	}
+}
+
+func TestAutoProgress(t *testing.T) {
+	tty, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tty.Close()
+	if !isTerminal(tty) {
+		t.Skipf("%s is not a character device", os.DevNull)
+	}
+	tests := []struct {
+		w    io.Writer
+		ci   string
+		want Progress
+	}{
+		{tty, "", &barProgress{}},
+		{tty, "true", &barProgress{}},
+		{new(bytes.Buffer), "true", &ciProgress{}},
+		{new(bytes.Buffer), "", &linesProgress{}},
+	}
+	for _, tt := range tests {
+		t.Setenv("CI", tt.ci)
+		for _, mode := range []string{"auto", ""} {
+			p, err := NewProgress(mode, tt.w, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := fmt.Sprintf("%T", p), fmt.Sprintf("%T", tt.want); got != want {
+				t.Errorf("NewProgress(%q) to %T with CI=%q = %s; want %s", mode, tt.w, tt.ci, got, want)
+			}
+		}
+	}
+}
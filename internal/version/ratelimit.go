@@ -0,0 +1,156 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parseRate parses a rate in bytes per second, such as "500K", "2MB/s"
+// or "1.5M". The K, M and G suffixes are powers of 1024, as printed by
+// fmtSize.
+func parseRate(s string) (int64, error) {
+	num := strings.TrimSuffix(strings.TrimSpace(s), "/s")
+	num = strings.TrimSuffix(strings.ToUpper(num), "B")
+	mult := 1.0
+	if n := len(num); n > 0 {
+		switch num[n-1] {
+		case 'K':
+			mult = 1 << 10
+		case 'M':
+			mult = 1 << 20
+		case 'G':
+			mult = 1 << 30
+		}
+		if mult != 1 {
+			num = num[:n-1]
+		}
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid rate %q; want bytes per second, such as 500K or 2M", s)
+	}
+	return int64(f * mult), nil
+}
+
+// rateSetting returns the GODL_RATE_LIMIT setting, or 0 if it is unset
+// or invalid. It warns about invalid values, which leave downloads
+// unlimited.
+func (in *Installer) rateSetting() int64 {
+	v := getenv("GODL_RATE_LIMIT")
+	if v == "" {
+		return 0
+	}
+	rate, err := parseRate(v)
+	if err != nil {
+		in.warnSetting("GODL_RATE_LIMIT", v, errors.New("want bytes per second, such as 500K or 2M; downloading without a limit"))
+		return 0
+	}
+	return rate
+}
+
+// A rateLimiter paces the bytes read through any number of rateReaders
+// to rate bytes per second in total.
+type rateLimiter struct {
+	rate int64
+
+	mu   sync.Mutex
+	next time.Time // when the bytes read so far are paid for
+}
+
+// wait blocks until n more bytes are within the rate limit.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(n) * time.Second / time.Duration(l.rate))
+	d := l.next.Sub(now)
+	l.mu.Unlock()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// A rateReader reads from r within the limit of l.
+type rateReader struct {
+	ctx context.Context
+	r   io.ReadCloser
+	l   *rateLimiter
+}
+
+func (r *rateReader) Read(p []byte) (int, error) {
+	// Read at most a tenth of a second's worth at a time, so that the
+	// rate stays even.
+	max := int(r.l.rate / 10)
+	if max < 1 {
+		max = 1
+	}
+	if len(p) > max {
+		p = p[:max]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.l.wait(r.ctx, n); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return n, err
+}
+
+func (r *rateReader) Close() error { return r.r.Close() }
+
+// limitOpen returns open with the readers it returns limited to
+// in.RateLimit bytes per second in total, or open itself if there is
+// no limit.
+func (in *Installer) limitOpen(open openFunc) openFunc {
+	if in.RateLimit <= 0 {
+		return open
+	}
+	l := &rateLimiter{rate: in.RateLimit}
+	return func(ctx context.Context, offset, length int64) (io.ReadCloser, int64, int64, error) {
+		r, start, size, err := open(ctx, offset, length)
+		if err != nil {
+			return r, start, size, err
+		}
+		return &rateReader{ctx, r, l}, start, size, nil
+	}
+}
+
+// rateProgress adds the rate limit to download progress events.
+type rateProgress struct {
+	Progress
+	limit int64
+}
+
+func (p rateProgress) Report(ev ProgressEvent) {
+	if ev.Phase == PhaseDownload {
+		ev.RateLimit = p.limit
+	}
+	p.Progress.Report(ev)
+}
+
+// downloadProgress returns the Progress for downloads, which reports
+// the rate limit if there is one.
+func (in *Installer) downloadProgress() Progress {
+	if in.RateLimit <= 0 {
+		return in.progress()
+	}
+	return rateProgress{in.progress(), in.RateLimit}
+}
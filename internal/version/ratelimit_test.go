@@ -0,0 +1,118 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"1000", 1000, true},
+		{"500K", 500 << 10, true},
+		{"2MB/s", 2 << 20, true},
+		{"1.5m", 3 << 19, true},
+		{"1G", 1 << 30, true},
+		{"", 0, false},
+		{"fast", 0, false},
+		{"-1K", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseRate(tt.in)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("parseRate(%q) = %d, %v; want %d, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRateSetting(t *testing.T) {
+	t.Setenv("GODL_CONFIG", filepath.Join(t.TempDir(), "no-such-config"))
+	var buf bytes.Buffer
+	in := &Installer{Logf: func(format string, args ...any) { fmt.Fprintf(&buf, format+"\n", args...) }}
+
+	t.Setenv("GODL_RATE_LIMIT", "2MB")
+	if got := in.rateSetting(); got != 2<<20 || buf.Len() != 0 {
+		t.Errorf("rateSetting with 2MB = %d, warnings %q; want %d and none", got, buf.String(), 2<<20)
+	}
+	t.Setenv("GODL_RATE_LIMIT", "2 megs")
+	if got := in.rateSetting(); got != 0 || !strings.Contains(buf.String(), "GODL_RATE_LIMIT=2 megs") {
+		t.Errorf("rateSetting with 2 megs = %d, warnings %q; want 0 and a warning", got, buf.String())
+	}
+}
+
+// recordProgress records the events it receives.
+type recordProgress struct {
+	mu     sync.Mutex
+	events []ProgressEvent
+}
+
+func (p *recordProgress) Report(ev ProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, ev)
+}
+
+func TestCopyFromURLRateLimit(t *testing.T) {
+	defer func(old int64) { minChunkSize = old }(minChunkSize)
+	minChunkSize = 1000
+	content := bytes.Repeat([]byte("0123456789"), 500)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		chunks  int
+		partial int // bytes already downloaded
+	}{
+		{"chunked", 4, 0},
+		{"resumed", 1, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "archive.tar.gz")
+			if err := os.WriteFile(dst+".partial", content[:tt.partial], 0666); err != nil {
+				t.Fatal(err)
+			}
+			p := new(recordProgress)
+			in := &Installer{Progress: p, Logf: t.Logf, Chunks: tt.chunks, RateLimit: 10000}
+			start := time.Now()
+			if err := in.copyFromURL(context.Background(), dst, srv.URL, int64(len(content))); err != nil {
+				t.Fatal(err)
+			}
+			elapsed := time.Since(start)
+			if got, err := os.ReadFile(dst); err != nil || !bytes.Equal(got, content) {
+				t.Errorf("download has %d bytes, %v; want the %d original bytes", len(got), err, len(content))
+			}
+			// The remaining bytes take at least their share of a
+			// second at 10000 bytes per second, less a little slack.
+			min := time.Duration(len(content)-tt.partial) * time.Second / 10000 * 8 / 10
+			if elapsed < min {
+				t.Errorf("download took %v; want at least %v", elapsed, min)
+			}
+			for _, ev := range p.events {
+				if ev.RateLimit != 10000 {
+					t.Errorf("progress event %+v does not report the rate limit", ev)
+					break
+				}
+			}
+		})
+	}
+}
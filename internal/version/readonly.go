@@ -0,0 +1,114 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// Installed versions are made read-only once installed, as the go
+// command makes the module cache read-only, so that nothing edits them
+// by accident. Only the files of the distribution are: the version
+// directory itself stays writable, as do the sentinel, receipt and
+// archive in it.
+//
+// On Unix, removing a file takes write permission on its directory but
+// not on the file, so making a tree writable again, before removing or
+// adding files, only restores the write permission of its directories.
+// Files that are hard links to blobs in the store thus stay read-only,
+// as the blobs must. On Windows, read-only files cannot be removed, so
+// files are made writable too.
+
+// readOnlySetting reports whether the GODL_READONLY setting asks for
+// read-only installs, as it does if unset or invalid. It warns about
+// values that are not booleans.
+func (in *Installer) readOnlySetting() bool {
+	v := getenv("GODL_READONLY")
+	if v == "" {
+		return true
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		in.warnSetting("GODL_READONLY", v, errors.New("want true or false"))
+		return true
+	}
+	return b
+}
+
+// walkDistribution calls fn for each file and directory of the
+// distribution installed in dir, directories before their contents,
+// skipping symbolic links and the files the installer added.
+func walkDistribution(dir string, fn func(name string, fi fs.FileInfo) error) error {
+	return filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		if rel == "." || d.Type()&fs.ModeSymlink != 0 || isMetadata(filepath.ToSlash(rel)) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(name, fi)
+	})
+}
+
+// makeReadOnly removes write permission from the distribution installed
+// in dir. Directories are done last, after their contents, since
+// listing a directory does not need write permission but changing what
+// is in it does.
+func makeReadOnly(dir string) error {
+	var dirs []string
+	err := walkDistribution(dir, func(name string, fi fs.FileInfo) error {
+		if fi.IsDir() {
+			dirs = append(dirs, name)
+			return nil
+		}
+		if fi.Mode().Perm()&0222 == 0 {
+			return nil
+		}
+		return os.Chmod(name, fi.Mode().Perm()&^0222)
+	})
+	if err != nil {
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		fi, err := os.Stat(dirs[i])
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(dirs[i], fi.Mode()&(fs.ModePerm|fs.ModeSetgid)&^0222); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// makeWritable restores write permission to the directories of the
+// distribution installed in dir, and on Windows to its files, so that
+// files can be removed or added. It does nothing to a tree that is not
+// read-only.
+func makeWritable(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	write := groupPerm(0200, isGroupShared(dir))
+	return walkDistribution(dir, func(name string, fi fs.FileInfo) error {
+		if !fi.IsDir() && runtime.GOOS != "windows" || fi.Mode().Perm()&write == write {
+			return nil
+		}
+		return os.Chmod(name, fi.Mode()&(fs.ModePerm|fs.ModeSetgid)|write)
+	})
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeBits returns the files and directories of the distribution in
+// dir that have any write permission.
+func writeBits(t *testing.T, dir string) []string {
+	t.Helper()
+	var list []string
+	err := walkDistribution(dir, func(name string, fi fs.FileInfo) error {
+		if fi.Mode().Perm()&0222 != 0 {
+			list = append(list, name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func TestReadOnlySetting(t *testing.T) {
+	t.Setenv("GODL_CONFIG", filepath.Join(t.TempDir(), "no-such-config"))
+	var buf bytes.Buffer
+	in := &Installer{Logf: func(format string, args ...any) { fmt.Fprintf(&buf, format+"\n", args...) }}
+
+	for _, tt := range []struct {
+		v    string
+		want bool
+		warn bool
+	}{
+		{"", true, false},
+		{"false", false, false},
+		{"0", false, false},
+		{"true", true, false},
+		{"off", true, true},
+	} {
+		buf.Reset()
+		t.Setenv("GODL_READONLY", tt.v)
+		if got := in.readOnlySetting(); got != tt.want {
+			t.Errorf("readOnlySetting with GODL_READONLY=%q = %v; want %v", tt.v, got, tt.want)
+		}
+		if warned := strings.Contains(buf.String(), "GODL_READONLY="+tt.v); warned != tt.warn {
+			t.Errorf("GODL_READONLY=%q warned %v; want %v:\n%s", tt.v, warned, tt.warn, buf.String())
+		}
+	}
+}
+
+func TestInstallReadOnly(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake go command is a shell script")
+	}
+	in := &Installer{BaseURL: profileArchive(t, "go1.99.1", "exit 0"), ChecksumURL: ChecksumFromMirror, Progress: quietProgress{}, Logf: t.Logf, Profile: Profile{Name: "minimal"}, ReadOnly: true}
+	target := filepath.Join(t.TempDir(), "go1.99.1")
+	t.Cleanup(func() { makeWritable(target) })
+	if err := in.Install(context.Background(), target, "go1.99.1"); err != nil {
+		t.Fatal(err)
+	}
+	if w := writeBits(t, target); len(w) > 0 {
+		t.Errorf("after install, writable: %v", w)
+	}
+	if fi, err := os.Stat(target); err != nil || fi.Mode().Perm()&0200 == 0 {
+		t.Errorf("version directory is not writable: %v, %v", fi.Mode(), err)
+	}
+	r, err := ReadReceipt(target)
+	if err != nil || !r.ReadOnly {
+		t.Errorf("receipt = %+v, %v; want read-only recorded", r, err)
+	}
+	if os.Getuid() != 0 {
+		if err := os.WriteFile(filepath.Join(target, "src/fmt/print.go"), []byte("edited"), 0644); err == nil {
+			t.Errorf("editing src/fmt/print.go succeeded; want permission error")
+		}
+		if err := os.WriteFile(filepath.Join(target, "src/fmt/new.go"), []byte("new"), 0644); err == nil {
+			t.Errorf("adding src/fmt/new.go succeeded; want permission error")
+		}
+	}
+
+	// Upgrading in place and deduplicating keep the tree read-only.
+	in.Profile = Profile{}
+	if err := in.Install(context.Background(), target, "go1.99.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(target, "doc/go_spec.html")); err != nil {
+		t.Errorf("upgrade did not add doc: %v", err)
+	}
+	if _, err := dedupe(target, "hardlink"); err != nil {
+		t.Fatal(err)
+	}
+	if w := writeBits(t, target); len(w) > 0 {
+		t.Errorf("after upgrade and dedupe, writable: %v", w)
+	}
+
+	// Restoring write permission does so for directories only, so that
+	// files shared through the store stay read-only.
+	if err := makeWritable(target); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(filepath.Join(target, "src/fmt")); err != nil || fi.Mode().Perm()&0200 == 0 {
+		t.Errorf("after makeWritable, src/fmt mode = %v, %v; want writable", fi.Mode(), err)
+	}
+	if fi, err := os.Stat(filepath.Join(target, "src/fmt/print.go")); err != nil || fi.Mode().Perm()&0222 != 0 {
+		t.Errorf("after makeWritable, src/fmt/print.go mode = %v, %v; want read-only", fi.Mode(), err)
+	}
+	if err := makeReadOnly(target); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RemoveVersion(target); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("after RemoveVersion, stat = %v; want not exist", err)
+	}
+}
+
+func TestMigrateReadOnly(t *testing.T) {
+	from, to := t.TempDir(), t.TempDir()
+	dir := writeVersion(t, from, "go1.99.1", map[string]string{"VERSION": "go1.99.1", "src/fmt/print.go": "package fmt"})
+	if err := writeReceipt(&Receipt{Version: "go1.99.1", Root: dir, ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := makeReadOnly(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { makeWritable(dir); makeWritable(filepath.Join(to, "go1.99.1")) })
+
+	// Force a copy, as between file systems.
+	dst := filepath.Join(to, "go1.99.1")
+	if err := os.Mkdir(dst+".partial", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyTree(dst+".partial", dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(dst+".partial", dst); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveVersion(dir); err != nil {
+		t.Fatalf("removing read-only copy source: %v", err)
+	}
+	if err := migrate(to, from, nil); err != nil {
+		t.Fatal(err)
+	}
+	if w := writeBits(t, filepath.Join(from, "go1.99.1")); len(w) > 0 {
+		t.Errorf("after migrate, writable: %v", w)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// receiptFile is the name of the file, next to unpackedOkay, that
+// records how a version was installed.
+const receiptFile = ".receipt.json"
+
+// A Receipt describes an installed version.
+type Receipt struct {
+	Version     string    `json:"version"`
+	Root        string    `json:"root"`               // directory the version is installed in
+	URL         string    `json:"url"`                // archive the version was installed from
+	Mirror      string    `json:"mirror"`             // source that served the archive
+	SHA256      string    `json:"sha256"`             // of the archive
+	Platform    string    `json:"platform,omitempty"` // of the archive, such as linux/amd64
+	Profile     *Profile  `json:"profile,omitempty"`  // files installed, if not all
+	ReadOnly    bool      `json:"readOnly,omitempty"` // whether the files were made read-only
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+// ReadReceipt reads the receipt of the version installed in root.
+func ReadReceipt(root string) (*Receipt, error) {
+	data, err := os.ReadFile(filepath.Join(root, receiptFile))
+	if err != nil {
+		return nil, err
+	}
+	r := new(Receipt)
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// writeReceipt writes r to its install directory, r.Root.
+func writeReceipt(r *Receipt) error {
+	data, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.Root, receiptFile), append(data, '\n'), groupPerm(0644, isGroupShared(r.Root)))
+}
+
+// updateReceiptRoot rewrites the receipt of the version installed in
+// root, if any, to record that it now lives there.
+func updateReceiptRoot(root string) error {
+	r, err := ReadReceipt(root)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.Root = root
+	return writeReceipt(r)
+}
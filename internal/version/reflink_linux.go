@@ -0,0 +1,46 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// ficlone is the FICLONE ioctl, which makes a file share the contents
+// of another, copy on write, on file systems such as Btrfs and XFS.
+const ficlone = 0x40049409
+
+// reflink makes dst, which must not exist, a copy-on-write clone of
+// src. It returns an error wrapping errNoReflink if the file system
+// cannot do that.
+func reflink(dst, src string) error {
+	s, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	fi, err := s.Stat()
+	if err != nil {
+		return err
+	}
+	d, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.Fd(), ficlone, s.Fd())
+	err = d.Close()
+	switch {
+	case errno == syscall.EOPNOTSUPP || errno == syscall.EXDEV || errno == syscall.EINVAL || errno == syscall.ENOTTY:
+		err = fmt.Errorf("%w: %v", errNoReflink, errno)
+	case errno != 0:
+		err = &os.PathError{Op: "reflink", Path: dst, Err: errno}
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
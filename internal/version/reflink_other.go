@@ -0,0 +1,13 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package version
+
+// reflink makes dst a copy-on-write clone of src. It is only
+// implemented on Linux.
+func reflink(dst, src string) error {
+	return errNoReflink
+}
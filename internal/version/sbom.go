@@ -0,0 +1,545 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SBOMFormats lists the formats that dl sbom writes: SPDX 2.3 as JSON
+// or tag-value, and CycloneDX 1.5 as JSON.
+var SBOMFormats = []string{"spdx-json", "spdx", "cyclonedx-json"}
+
+// sbomTool names the tool that creates documents, as SPDX and CycloneDX
+// record it.
+const sbomTool = "godl"
+
+// An sbomFile is a file of a distribution, with its checksums.
+type sbomFile struct {
+	rel    string // slash-separated path relative to the distribution root
+	sha256 string // hex
+	sha1   string // hex, or "" if not computed
+}
+
+// An sbomInfo describes a distribution for a software bill of
+// materials.
+type sbomInfo struct {
+	version string
+	url     string // the archive downloaded, or "" if unknown
+	sha256  string // of the archive, or "" if unknown
+	files   []sbomFile
+	license string // contents of LICENSE, or "" if missing
+	patents string // contents of PATENTS, or "" if missing
+}
+
+// needsSHA1 reports whether format records the SHA-1 of each file, as
+// SPDX 2.3 requires.
+func needsSHA1(format string) bool {
+	return strings.HasPrefix(format, "spdx")
+}
+
+// treeSBOM describes the version installed in dir. The archive it was
+// installed from comes from its receipt. The checksums of its files
+// come from its manifest, if it has one and they are all that is
+// needed, and otherwise from reading every file.
+func treeSBOM(dir, version string, needSHA1 bool) (*sbomInfo, error) {
+	if !IsInstalled(dir) {
+		return nil, fmt.Errorf("%s: %w in %s", version, ErrNotInstalled, dir)
+	}
+	s := &sbomInfo{version: version}
+	if r, err := ReadReceipt(dir); err == nil {
+		s.url, s.sha256 = r.URL, r.SHA256
+	}
+	if list, err := readManifest(dir); err == nil && !needSHA1 {
+		for _, e := range list {
+			s.files = append(s.files, sbomFile{rel: e.rel, sha256: e.sum})
+		}
+	} else {
+		err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, name)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if rel == "." || !d.Type().IsRegular() || isMetadata(rel) {
+				return nil
+			}
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			file, err := hashSBOMFile(rel, f, nil)
+			s.files = append(s.files, file)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range []struct {
+		name string
+		text *string
+	}{{"LICENSE", &s.license}, {"PATENTS", &s.patents}} {
+		data, err := os.ReadFile(filepath.Join(dir, t.name))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		*t.text = string(data)
+	}
+	sort.Slice(s.files, func(i, j int) bool { return s.files[i].rel < s.files[j].rel })
+	return s, nil
+}
+
+// archiveSBOM describes the distribution in the named release archive,
+// reading every file in it.
+func archiveSBOM(file string) (*sbomInfo, error) {
+	rf, ok := parseArchiveName(filepath.Base(file))
+	if !ok || rf.Kind != "archive" {
+		return nil, fmt.Errorf("%s: not named like a release archive, such as go1.22.5.linux-amd64.tar.gz", file)
+	}
+	sum, err := hashFile(file)
+	if err != nil {
+		return nil, err
+	}
+	s := &sbomInfo{version: rf.Version, sha256: sum}
+	add := func(name string, r io.Reader) error {
+		rel, err := entryPath(name)
+		if err != nil {
+			return err
+		}
+		var text *string
+		switch rel {
+		case "LICENSE":
+			text = &s.license
+		case "PATENTS":
+			text = &s.patents
+		}
+		f, err := hashSBOMFile(rel, r, text)
+		s.files = append(s.files, f)
+		return err
+	}
+
+	if strings.HasSuffix(file, ".zip") {
+		zr, err := zip.OpenReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		for _, f := range zr.File {
+			if !f.Mode().IsRegular() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			err = add(f.Name, rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		tr := tar.NewReader(zr)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if h.Typeflag != tar.TypeReg {
+				continue
+			}
+			if err := add(h.Name, tr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Slice(s.files, func(i, j int) bool { return s.files[i].rel < s.files[j].rel })
+	return s, nil
+}
+
+// hashSBOMFile returns the checksums of the file rel, read from r, and
+// stores its contents in text if not nil.
+func hashSBOMFile(rel string, r io.Reader, text *string) (sbomFile, error) {
+	h256, h1 := sha256.New(), sha1.New()
+	w := io.MultiWriter(h256, h1)
+	var b bytes.Buffer
+	if text != nil {
+		w = io.MultiWriter(w, &b)
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return sbomFile{}, fmt.Errorf("%s: %v", rel, err)
+	}
+	if text != nil {
+		*text = b.String()
+	}
+	return sbomFile{rel: rel, sha256: fmt.Sprintf("%x", h256.Sum(nil)), sha1: fmt.Sprintf("%x", h1.Sum(nil))}, nil
+}
+
+// SPDX identifiers of the licensing information in LICENSE and PATENTS
+// that is not on the SPDX license list.
+const (
+	licenseRef = "LicenseRef-Go-LICENSE"
+	patentsRef = "LicenseRef-Go-PATENTS"
+)
+
+// licenseID returns the SPDX identifier of the license in LICENSE:
+// BSD-3-Clause for the license of Go, or licenseRef for any other.
+func (s *sbomInfo) licenseID() string {
+	if strings.Contains(s.license, "Redistribution and use in source and binary forms") &&
+		strings.Contains(s.license, "Neither the name of") {
+		return "BSD-3-Clause"
+	}
+	return licenseRef
+}
+
+// licenseExpr returns the SPDX license expression of the distribution,
+// which includes the patent grant in PATENTS, or NOASSERTION if it has
+// neither LICENSE nor PATENTS.
+func (s *sbomInfo) licenseExpr() string {
+	var ids []string
+	if s.license != "" {
+		ids = append(ids, s.licenseID())
+	}
+	if s.patents != "" {
+		ids = append(ids, patentsRef)
+	}
+	if len(ids) == 0 {
+		return "NOASSERTION"
+	}
+	return strings.Join(ids, " AND ")
+}
+
+// extractedLicense is licensing information that the SPDX license
+// list lacks, with its text.
+type extractedLicense struct {
+	ID   string `json:"licenseId"`
+	Name string `json:"name"`
+	Text string `json:"extractedText"`
+}
+
+// extracted returns the licensing information of the distribution that
+// the SPDX license list lacks.
+func (s *sbomInfo) extracted() []extractedLicense {
+	var list []extractedLicense
+	if s.license != "" && s.licenseID() == licenseRef {
+		list = append(list, extractedLicense{licenseRef, "Go license", s.license})
+	}
+	if s.patents != "" {
+		list = append(list, extractedLicense{patentsRef, "Go additional IP rights grant", s.patents})
+	}
+	return list
+}
+
+// copyright returns the copyright notice at the start of LICENSE, or
+// NOASSERTION.
+func (s *sbomInfo) copyright() string {
+	for _, line := range strings.Split(s.license, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "Copyright") {
+			return line
+		}
+	}
+	return "NOASSERTION"
+}
+
+// downloadLocation returns the URL of the archive, or NOASSERTION.
+func (s *sbomInfo) downloadLocation() string {
+	if s.url == "" {
+		return "NOASSERTION"
+	}
+	return s.url
+}
+
+// digest returns a hex SHA-256 that identifies the contents that s
+// describes, for naming the document.
+func (s *sbomInfo) digest() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", s.version, s.sha256)
+	for _, f := range s.files {
+		fmt.Fprintf(h, "%s %s\n", f.sha256, f.rel)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// verificationCode returns the SPDX package verification code of the
+// files: the SHA-1 of their sorted SHA-1s.
+func (s *sbomInfo) verificationCode() string {
+	var sums []string
+	for _, f := range s.files {
+		sums = append(sums, f.sha1)
+	}
+	sort.Strings(sums)
+	return fmt.Sprintf("%x", sha1.Sum([]byte(strings.Join(sums, ""))))
+}
+
+// write writes a document describing s in format to w, as created at
+// the given time.
+func (s *sbomInfo) write(w io.Writer, format string, created time.Time) error {
+	switch format {
+	case "spdx-json":
+		return s.writeSPDXJSON(w, created)
+	case "spdx":
+		return s.writeSPDXTagValue(w, created)
+	case "cyclonedx-json":
+		return s.writeCycloneDX(w, created)
+	}
+	return checkSBOMFormat(format)
+}
+
+// checkSBOMFormat reports an error if format is not one of SBOMFormats.
+func checkSBOMFormat(format string) error {
+	for _, f := range SBOMFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown SBOM format %q; want one of %s", format, strings.Join(SBOMFormats, ", "))
+}
+
+const spdxPackageID = "SPDXRef-Package-go"
+
+func spdxFileID(i int) string { return fmt.Sprintf("SPDXRef-File-%d", i+1) }
+
+func (s *sbomInfo) spdxNamespace() string {
+	return "https://github.com/LetFu/dl/spdx/" + s.version + "-" + s.digest()[:16]
+}
+
+type spdxChecksum struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"checksumValue"`
+}
+
+func (f sbomFile) spdxChecksums() []spdxChecksum {
+	return []spdxChecksum{{"SHA1", f.sha1}, {"SHA256", f.sha256}}
+}
+
+func (s *sbomInfo) writeSPDXJSON(w io.Writer, created time.Time) error {
+	type file struct {
+		Name             string         `json:"fileName"`
+		ID               string         `json:"SPDXID"`
+		Checksums        []spdxChecksum `json:"checksums"`
+		LicenseConcluded string         `json:"licenseConcluded"`
+		Copyright        string         `json:"copyrightText"`
+	}
+	type pkg struct {
+		ID               string         `json:"SPDXID"`
+		Name             string         `json:"name"`
+		Version          string         `json:"versionInfo"`
+		DownloadLocation string         `json:"downloadLocation"`
+		FilesAnalyzed    bool           `json:"filesAnalyzed"`
+		VerificationCode map[string]any `json:"packageVerificationCode"`
+		Checksums        []spdxChecksum `json:"checksums,omitempty"`
+		LicenseConcluded string         `json:"licenseConcluded"`
+		LicenseDeclared  string         `json:"licenseDeclared"`
+		Copyright        string         `json:"copyrightText"`
+		HasFiles         []string       `json:"hasFiles"`
+	}
+	type relationship struct {
+		Element string `json:"spdxElementId"`
+		Type    string `json:"relationshipType"`
+		Related string `json:"relatedSpdxElement"`
+	}
+	doc := struct {
+		Version      string             `json:"spdxVersion"`
+		DataLicense  string             `json:"dataLicense"`
+		ID           string             `json:"SPDXID"`
+		Name         string             `json:"name"`
+		Namespace    string             `json:"documentNamespace"`
+		CreationInfo map[string]any     `json:"creationInfo"`
+		Packages     []pkg              `json:"packages"`
+		Files        []file             `json:"files"`
+		Extracted    []extractedLicense `json:"hasExtractedLicensingInfos,omitempty"`
+		Relations    []relationship     `json:"relationships"`
+	}{
+		Version:     "SPDX-2.3",
+		DataLicense: "CC0-1.0",
+		ID:          "SPDXRef-DOCUMENT",
+		Name:        s.version,
+		Namespace:   s.spdxNamespace(),
+		CreationInfo: map[string]any{
+			"created":  created.UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: " + sbomTool},
+		},
+		Extracted: s.extracted(),
+		Relations: []relationship{{"SPDXRef-DOCUMENT", "DESCRIBES", spdxPackageID}},
+	}
+	p := pkg{
+		ID:               spdxPackageID,
+		Name:             "go",
+		Version:          strings.TrimPrefix(s.version, "go"),
+		DownloadLocation: s.downloadLocation(),
+		FilesAnalyzed:    true,
+		VerificationCode: map[string]any{"packageVerificationCodeValue": s.verificationCode()},
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  s.licenseExpr(),
+		Copyright:        s.copyright(),
+		HasFiles:         []string{},
+	}
+	if s.sha256 != "" {
+		p.Checksums = []spdxChecksum{{"SHA256", s.sha256}}
+	}
+	doc.Files = []file{}
+	for i, f := range s.files {
+		p.HasFiles = append(p.HasFiles, spdxFileID(i))
+		doc.Files = append(doc.Files, file{"./" + f.rel, spdxFileID(i), f.spdxChecksums(), "NOASSERTION", "NOASSERTION"})
+	}
+	doc.Packages = []pkg{p}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(doc)
+}
+
+// spdxText returns s as a tag-value text value, which may span lines.
+func spdxText(s string) string {
+	return "<text>" + s + "</text>"
+}
+
+func (s *sbomInfo) writeSPDXTagValue(w io.Writer, created time.Time) error {
+	var b strings.Builder
+	tag := func(name, value string) { fmt.Fprintf(&b, "%s: %s\n", name, value) }
+	tag("SPDXVersion", "SPDX-2.3")
+	tag("DataLicense", "CC0-1.0")
+	tag("SPDXID", "SPDXRef-DOCUMENT")
+	tag("DocumentName", s.version)
+	tag("DocumentNamespace", s.spdxNamespace())
+	tag("Creator", "Tool: "+sbomTool)
+	tag("Created", created.UTC().Format(time.RFC3339))
+	tag("Relationship", "SPDXRef-DOCUMENT DESCRIBES "+spdxPackageID)
+
+	b.WriteString("\n")
+	tag("PackageName", "go")
+	tag("SPDXID", spdxPackageID)
+	tag("PackageVersion", strings.TrimPrefix(s.version, "go"))
+	tag("PackageDownloadLocation", s.downloadLocation())
+	tag("FilesAnalyzed", "true")
+	tag("PackageVerificationCode", s.verificationCode())
+	if s.sha256 != "" {
+		tag("PackageChecksum", "SHA256: "+s.sha256)
+	}
+	tag("PackageLicenseConcluded", "NOASSERTION")
+	tag("PackageLicenseDeclared", s.licenseExpr())
+	tag("PackageCopyrightText", spdxText(s.copyright()))
+
+	// Files that follow a package belong to it.
+	for i, f := range s.files {
+		b.WriteString("\n")
+		tag("FileName", "./"+f.rel)
+		tag("SPDXID", spdxFileID(i))
+		for _, c := range f.spdxChecksums() {
+			tag("FileChecksum", c.Algorithm+": "+c.Value)
+		}
+		tag("LicenseConcluded", "NOASSERTION")
+		tag("FileCopyrightText", "NOASSERTION")
+	}
+
+	for _, l := range s.extracted() {
+		b.WriteString("\n")
+		tag("LicenseID", l.ID)
+		tag("ExtractedText", spdxText(l.Text))
+		tag("LicenseName", l.Name)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (s *sbomInfo) writeCycloneDX(w io.Writer, created time.Time) error {
+	type hash struct {
+		Alg     string `json:"alg"`
+		Content string `json:"content"`
+	}
+	type license struct {
+		ID   string         `json:"id,omitempty"`
+		Name string         `json:"name,omitempty"`
+		Text map[string]any `json:"text,omitempty"`
+	}
+	type licenseChoice struct {
+		License license `json:"license"`
+	}
+	type component struct {
+		Type     string           `json:"type"`
+		BOMRef   string           `json:"bom-ref,omitempty"`
+		Name     string           `json:"name"`
+		Version  string           `json:"version,omitempty"`
+		Hashes   []hash           `json:"hashes,omitempty"`
+		Licenses []licenseChoice  `json:"licenses,omitempty"`
+		Refs     []map[string]any `json:"externalReferences,omitempty"`
+	}
+
+	digest := s.digest()
+	root := component{
+		Type:    "application",
+		BOMRef:  "go@" + strings.TrimPrefix(s.version, "go"),
+		Name:    "go",
+		Version: strings.TrimPrefix(s.version, "go"),
+	}
+	if s.sha256 != "" {
+		root.Hashes = []hash{{"SHA-256", s.sha256}}
+	}
+	if s.url != "" {
+		root.Refs = []map[string]any{{"type": "distribution", "url": s.url}}
+	}
+	if s.license != "" {
+		if id := s.licenseID(); id != licenseRef {
+			root.Licenses = append(root.Licenses, licenseChoice{license{ID: id}})
+		} else {
+			root.Licenses = append(root.Licenses, licenseChoice{license{Name: "Go license", Text: map[string]any{"content": s.license}}})
+		}
+	}
+	if s.patents != "" {
+		root.Licenses = append(root.Licenses, licenseChoice{license{Name: "Go additional IP rights grant", Text: map[string]any{"content": s.patents}}})
+	}
+	// The files are the components of the distribution, which the
+	// metadata describes.
+	files := []component{}
+	for _, f := range s.files {
+		files = append(files, component{Type: "file", BOMRef: "file:" + f.rel, Name: f.rel, Hashes: []hash{{"SHA-256", f.sha256}}})
+	}
+	doc := map[string]any{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.5",
+		"serialNumber": fmt.Sprintf("urn:uuid:%s-%s-5%s-%s-%s",
+			digest[0:8], digest[8:12], digest[13:16], "8"+digest[17:20], digest[20:32]),
+		"version": 1,
+		"metadata": map[string]any{
+			"timestamp": created.UTC().Format(time.RFC3339),
+			"tools":     map[string]any{"components": []component{{Type: "application", Name: sbomTool}}},
+			"component": root,
+		},
+		"components": files,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(doc)
+}
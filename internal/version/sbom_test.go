@@ -0,0 +1,202 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testLicense = `Copyright 2009 The Go Authors.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are
+met:
+...
+   * Neither the name of Google LLC nor the names of its
+contributors may be used to endorse or promote products derived from
+this software without specific prior written permission.
+`
+
+func TestSBOM(t *testing.T) {
+	dir := t.TempDir()
+	archive := testArchive(t, map[string]string{
+		"VERSION":          "go1.99.1",
+		"LICENSE":          testLicense,
+		"PATENTS":          "Additional IP Rights Grant (Patents)",
+		"bin/go":           "binary",
+		"src/fmt/print.go": "package fmt",
+	})
+	file := filepath.Join(dir, archiveName("go1.99.1"))
+	if err := os.WriteFile(file, archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file+".sha256", []byte(sha256Hex(archive)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fromArchive, err := archiveSBOM(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromArchive.version != "go1.99.1" || fromArchive.sha256 != sha256Hex(archive) || len(fromArchive.files) != 5 {
+		t.Errorf("archiveSBOM = %+v; want go1.99.1, the archive checksum and 5 files", fromArchive)
+	}
+	if got, want := fromArchive.licenseExpr(), "BSD-3-Clause AND "+patentsRef; got != want {
+		t.Errorf("license = %q; want %q", got, want)
+	}
+	if got := fromArchive.copyright(); got != "Copyright 2009 The Go Authors." {
+		t.Errorf("copyright = %q", got)
+	}
+
+	// The installed tree has the same files, and the source from its
+	// receipt.
+	in := &Installer{BaseURL: fileURL(dir), ChecksumURL: ChecksumFromMirror, Progress: quietProgress{}, Logf: t.Logf}
+	target := filepath.Join(t.TempDir(), "go1.99.1")
+	if err := in.Install(context.Background(), target, "go1.99.1"); err != nil {
+		t.Fatal(err)
+	}
+	fromTree, err := treeSBOM(target, "go1.99.1", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromTree.files, fromArchive.files) {
+		t.Errorf("installed files %v; want %v", fromTree.files, fromArchive.files)
+	}
+	if fromTree.sha256 != sha256Hex(archive) || !strings.HasSuffix(fromTree.url, "/"+archiveName("go1.99.1")) {
+		t.Errorf("installed from %s with SHA-256 %s; want the archive", fromTree.url, fromTree.sha256)
+	}
+
+	// Checksums come from the manifest where it has all that is needed.
+	if _, err := dedupe(target, "hardlink"); err != nil {
+		t.Fatal(err)
+	}
+	list, err := readManifest(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list[0].sum = strings.Repeat("0", 64)
+	if err := writeManifest(target, list); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := treeSBOM(target, "go1.99.1", false); err != nil || s.files[0].sha256 != list[0].sum {
+		t.Errorf("treeSBOM without SHA-1 did not use the manifest: %+v, %v", s, err)
+	}
+	if s, err := treeSBOM(target, "go1.99.1", true); err != nil || s.files[0].sha256 == list[0].sum {
+		t.Errorf("treeSBOM with SHA-1 used the manifest: %+v, %v", s, err)
+	}
+
+	if _, err := treeSBOM(filepath.Join(t.TempDir(), "go1.99.2"), "go1.99.2", false); err == nil {
+		t.Errorf("treeSBOM of a version not installed succeeded")
+	}
+}
+
+func TestSBOMFormats(t *testing.T) {
+	s := &sbomInfo{
+		version: "go1.99.1",
+		url:     "https://dl.google.com/go/go1.99.1.linux-amd64.tar.gz",
+		sha256:  strings.Repeat("ab", 32),
+		license: testLicense,
+		patents: "Additional IP Rights Grant (Patents)",
+	}
+	for _, name := range []string{"LICENSE", "bin/go"} {
+		f, err := hashSBOMFile(name, strings.NewReader(name), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.files = append(s.files, f)
+	}
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var b bytes.Buffer
+	if err := s.write(&b, "spdx-json", created); err != nil {
+		t.Fatal(err)
+	}
+	var spdx struct {
+		Version  string `json:"spdxVersion"`
+		Packages []struct {
+			Version   string         `json:"versionInfo"`
+			Location  string         `json:"downloadLocation"`
+			Checksums []spdxChecksum `json:"checksums"`
+			License   string         `json:"licenseDeclared"`
+			HasFiles  []string       `json:"hasFiles"`
+		} `json:"packages"`
+		Files []struct {
+			Name      string         `json:"fileName"`
+			Checksums []spdxChecksum `json:"checksums"`
+		} `json:"files"`
+		Extracted []extractedLicense `json:"hasExtractedLicensingInfos"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &spdx); err != nil {
+		t.Fatal(err)
+	}
+	if spdx.Version != "SPDX-2.3" || len(spdx.Packages) != 1 || len(spdx.Files) != 2 || len(spdx.Extracted) != 1 {
+		t.Fatalf("SPDX JSON = %s", b.Bytes())
+	}
+	p := spdx.Packages[0]
+	if p.Version != "1.99.1" || p.Location != s.url || len(p.Checksums) != 1 || p.Checksums[0].Value != s.sha256 || len(p.HasFiles) != 2 {
+		t.Errorf("SPDX package = %+v", p)
+	}
+	if f := spdx.Files[1]; f.Name != "./bin/go" || len(f.Checksums) != 2 || f.Checksums[1].Value != s.files[1].sha256 {
+		t.Errorf("SPDX file = %+v", f)
+	}
+
+	b.Reset()
+	if err := s.write(&b, "spdx", created); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"SPDXVersion: SPDX-2.3",
+		"Created: 2026-01-02T03:04:05Z",
+		"PackageChecksum: SHA256: " + s.sha256,
+		"PackageLicenseDeclared: BSD-3-Clause AND " + patentsRef,
+		"PackageCopyrightText: <text>Copyright 2009 The Go Authors.</text>",
+		"FileName: ./bin/go",
+		"FileChecksum: SHA1: " + s.files[1].sha1,
+		"LicenseID: " + patentsRef,
+		"ExtractedText: <text>Additional IP Rights Grant (Patents)</text>",
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("SPDX tag-value lacks %q:\n%s", line, b.String())
+		}
+	}
+
+	b.Reset()
+	if err := s.write(&b, "cyclonedx-json", created); err != nil {
+		t.Fatal(err)
+	}
+	var cdx struct {
+		Format   string `json:"bomFormat"`
+		Serial   string `json:"serialNumber"`
+		Metadata struct {
+			Component struct {
+				Version  string            `json:"version"`
+				Hashes   []json.RawMessage `json:"hashes"`
+				Licenses []json.RawMessage `json:"licenses"`
+			} `json:"component"`
+		} `json:"metadata"`
+		Components []struct {
+			Name string `json:"name"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &cdx); err != nil {
+		t.Fatal(err)
+	}
+	c := cdx.Metadata.Component
+	if cdx.Format != "CycloneDX" || len(cdx.Serial) != len("urn:uuid:")+36 || c.Version != "1.99.1" || len(c.Hashes) != 1 || len(c.Licenses) != 2 || len(cdx.Components) != 2 {
+		t.Errorf("CycloneDX JSON = %s", b.Bytes())
+	}
+
+	if err := s.write(&b, "spdx-xml", created); err == nil {
+		t.Errorf("writing unknown format succeeded")
+	}
+}
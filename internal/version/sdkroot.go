@@ -0,0 +1,330 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Goroot returns the directory in which the given version is installed,
+// or is to be installed if it is not yet. It searches the shared SDK
+// roots and then the per-user root, and only if none of them holds the
+// version picks the root to install it in.
+func Goroot(version string) (string, error) {
+	roots := sharedRoots()
+	if root, err := sdkRoot(); err == nil {
+		roots = append(roots, root)
+	}
+	for _, root := range roots {
+		dir := filepath.Join(root, version)
+		if IsInstalled(dir) {
+			return dir, nil
+		}
+	}
+	root, err := installRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, version), nil
+}
+
+// IsInstalled reports whether dir holds a successfully installed version.
+func IsInstalled(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, unpackedOkay))
+	return err == nil
+}
+
+// SDKRoots returns the SDK roots that Goroot searches, in order: the
+// shared roots followed by the per-user root.
+func SDKRoots() ([]string, error) {
+	root, err := sdkRoot()
+	if err != nil {
+		return nil, err
+	}
+	roots := sharedRoots()
+	for _, r := range roots {
+		if r == root {
+			return roots, nil
+		}
+	}
+	return append(roots, root), nil
+}
+
+// RemoveVersion removes the version installed in dir, and any blobs in
+// the store of its SDK root that only it used.
+func RemoveVersion(dir string) error {
+	// Remove the sentinel first, so that a partially removed
+	// tree is never mistaken for an installed version.
+	if err := os.Remove(filepath.Join(dir, unpackedOkay)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := makeWritable(dir); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	_, _, err := pruneStore(filepath.Dir(dir))
+	return err
+}
+
+// removeUnpacked removes everything in the version directory dir except
+// the named files, undoing a partial unpack.
+func removeUnpacked(dir string, keep ...string) error {
+	if err := makeWritable(dir); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+Entries:
+	for _, e := range entries {
+		for _, k := range keep {
+			if e.Name() == k {
+				continue Entries
+			}
+		}
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listUnpacked returns the paths, relative to dir and slash-separated,
+// of the files and directories unpacked in the version directory dir,
+// leaving out the files the installer added.
+func listUnpacked(dir string) (map[string]bool, error) {
+	had := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); rel != "." && !isMetadata(rel) {
+			had[rel] = true
+		}
+		return nil
+	})
+	return had, err
+}
+
+// removeAdded removes from the version directory dir the files and
+// directories unpacked since listUnpacked returned had, undoing a
+// partial unpack over an installed version.
+func removeAdded(dir string, had map[string]bool) error {
+	if err := makeWritable(dir); err != nil {
+		return err
+	}
+	return filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); rel == "." || isMetadata(rel) || had[rel] {
+			return nil
+		}
+		if err := os.RemoveAll(name); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// sdkRoot returns the directory holding all installed versions. It is,
+// in order of preference:
+//
+//   - the GODL_SDKROOT setting;
+//   - the sdk directory in the first element of $GOPATH;
+//   - $HOME/sdk, if it already exists;
+//   - godl/sdk under $XDG_DATA_HOME, if set;
+//   - $HOME/sdk.
+func sdkRoot() (string, error) {
+	if dir := getenv("GODL_SDKROOT"); dir != "" {
+		return filepath.Clean(dir), nil
+	}
+	if dir := firstGOPATH(); dir != "" {
+		return filepath.Join(dir, "sdk"), nil
+	}
+
+	home, err := homedir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	legacy := filepath.Join(home, "sdk")
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy, nil
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" && filepath.IsAbs(dir) {
+		return filepath.Join(dir, "godl", "sdk"), nil
+	}
+	return legacy, nil
+}
+
+// firstGOPATH returns the first non-empty element of $GOPATH, or "".
+func firstGOPATH() string {
+	for _, dir := range filepath.SplitList(os.Getenv("GOPATH")) {
+		if dir != "" {
+			return dir
+		}
+	}
+	return ""
+}
+
+// migrate moves the named versions, or all installed versions if none
+// are named, from the SDK root fromRoot to toRoot and rewrites their
+// receipts to record the new location. Versions already present in
+// toRoot are left alone. Deduplicated versions are linked to the store
+// of toRoot, and the blobs of fromRoot that only they used are removed.
+func migrate(fromRoot, toRoot string, versions []string) error {
+	fromRoot, toRoot = filepath.Clean(fromRoot), filepath.Clean(toRoot)
+	if fromRoot == toRoot {
+		return fmt.Errorf("source and destination are both %s", fromRoot)
+	}
+	if len(versions) == 0 {
+		entries, err := os.ReadDir(fromRoot)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.IsDir() && e.Name() != storeDir {
+				versions = append(versions, e.Name())
+			}
+		}
+	}
+	if err := os.MkdirAll(toRoot, 0755); err != nil {
+		return err
+	}
+	deduped := false
+	for _, v := range versions {
+		src, dst := filepath.Join(fromRoot, v), filepath.Join(toRoot, v)
+		if _, err := os.Stat(src); err != nil {
+			return err
+		}
+		if _, err := os.Stat(dst); err == nil {
+			log.Printf("%s: already present in %s; skipping", v, toRoot)
+			continue
+		}
+		log.Printf("%s: moving %s to %s ...", v, src, dst)
+		if err := moveDir(src, dst); err != nil {
+			return fmt.Errorf("%s: %v", v, err)
+		}
+		if err := updateReceiptRoot(dst); err != nil {
+			return fmt.Errorf("%s: rewriting receipt: %v", v, err)
+		}
+		if _, err := os.Stat(filepath.Join(dst, manifestFile)); err == nil {
+			mode := getenv("GODL_DEDUPE")
+			if !dedupeEnabled(mode) {
+				mode = "auto"
+			}
+			if _, err := dedupe(dst, mode); err != nil {
+				return fmt.Errorf("%s: sharing files in %s: %v", v, toRoot, err)
+			}
+			deduped = true
+		}
+		// A copy has writable directories.
+		if r, err := ReadReceipt(dst); err == nil && r.ReadOnly {
+			if err := makeReadOnly(dst); err != nil {
+				return fmt.Errorf("%s: %v", v, err)
+			}
+		}
+	}
+	if deduped {
+		if _, _, err := pruneStore(fromRoot); err != nil {
+			return fmt.Errorf("removing unused blobs from %s: %v", fromRoot, err)
+		}
+	}
+	return nil
+}
+
+// moveDir moves the directory src to dst, copying it if the two are on
+// different file systems.
+func moveDir(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	// Copy into a temporary name so that an interrupted copy is never
+	// mistaken for an installed version.
+	tmp := dst + ".partial"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := copyTree(tmp, src); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	if err := makeWritable(src); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyTree copies the directory tree rooted at src to dst, preserving
+// file modes and symbolic links.
+func copyTree(dst, src string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			return os.MkdirAll(target, mode.Perm()|0700)
+		case mode&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case mode.IsRegular():
+			return copyFile(target, path, mode.Perm())
+		default:
+			return fmt.Errorf("%s: unsupported file type %v", path, mode)
+		}
+	})
+}
+
+func copyFile(dst, src string, perm fs.FileMode) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
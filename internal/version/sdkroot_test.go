@@ -0,0 +1,193 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSDKRoot(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("GODL_CONFIG", filepath.Join(home, "no-such-config"))
+	t.Setenv("GODL_SDKROOT", "")
+	t.Setenv("XDG_DATA_HOME", "")
+
+	sep := string(filepath.ListSeparator)
+	tests := []struct {
+		sdkroot, gopath, xdg string
+		want                 string
+	}{
+		{want: filepath.Join(home, "sdk")},
+		{xdg: filepath.Join(home, "data"), want: filepath.Join(home, "data", "godl", "sdk")},
+		{gopath: filepath.Join(home, "a") + sep + filepath.Join(home, "b"), want: filepath.Join(home, "a", "sdk")},
+		{gopath: sep + filepath.Join(home, "b"), want: filepath.Join(home, "b", "sdk")},
+		{sdkroot: filepath.Join(home, "vol"), gopath: filepath.Join(home, "a"), want: filepath.Join(home, "vol")},
+	}
+	for _, tt := range tests {
+		t.Setenv("GODL_SDKROOT", tt.sdkroot)
+		t.Setenv("GOPATH", tt.gopath)
+		t.Setenv("XDG_DATA_HOME", tt.xdg)
+		got, err := sdkRoot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("sdkRoot() with GODL_SDKROOT=%q GOPATH=%q XDG_DATA_HOME=%q = %q; want %q", tt.sdkroot, tt.gopath, tt.xdg, got, tt.want)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	from, to := t.TempDir(), filepath.Join(t.TempDir(), "new")
+	src := filepath.Join(from, "go1.22.5")
+	if err := os.MkdirAll(filepath.Join(src, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "bin", "go"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeReceipt(&Receipt{Version: "go1.22.5", Root: src}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrate(from, to, nil); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(to, "go1.22.5")
+	if _, err := os.Stat(filepath.Join(dst, "bin", "go")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("%s still exists after migrate", src)
+	}
+	r, err := ReadReceipt(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Root != dst {
+		t.Errorf("receipt root = %q; want %q", r.Root, dst)
+	}
+}
+
+func TestGorootShared(t *testing.T) {
+	home := t.TempDir()
+	user, shared := filepath.Join(home, "sdk"), filepath.Join(home, "shared")
+	t.Setenv("GODL_CONFIG", filepath.Join(home, "no-such-config"))
+	t.Setenv("GODL_SDKROOT", user)
+	t.Setenv("GODL_SHAREDROOT", shared)
+
+	// Provision go1.22.5 in the shared root only.
+	if err := mkdirVersion(filepath.Join(shared, "go1.22.5"), true); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(shared, "go1.22.5", unpackedOkay), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !isGroupShared(filepath.Join(shared, "go1.22.5")) && runtime.GOOS != "windows" {
+		t.Errorf("shared version directory is not marked set-group-ID")
+	}
+
+	check := func(version, want string) {
+		t.Helper()
+		got, err := Goroot(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Goroot(%q) = %q; want %q", version, got, want)
+		}
+	}
+	check("go1.22.5", filepath.Join(shared, "go1.22.5"))
+	// The shared root is writable, so new versions go there.
+	check("go1.22.6", filepath.Join(shared, "go1.22.6"))
+
+	// A version already installed in the per-user root is found there,
+	// without probing whether the shared root is writable.
+	if err := os.MkdirAll(filepath.Join(user, "go1.21.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(user, "go1.21.0", unpackedOkay), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(shared, old, old); err != nil {
+		t.Fatal(err)
+	}
+	check("go1.21.0", filepath.Join(user, "go1.21.0"))
+	check("go1.22.5", filepath.Join(shared, "go1.22.5"))
+	if fi, err := os.Stat(shared); err != nil || !fi.ModTime().Equal(old) {
+		t.Errorf("shared root was written to when looking up installed versions")
+	}
+
+	if runtime.GOOS == "windows" || os.Getuid() == 0 {
+		return // can't make the shared root read-only
+	}
+	if err := os.Chmod(shared, 0555); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(shared, 0755)
+	check("go1.22.5", filepath.Join(shared, "go1.22.5"))
+	check("go1.22.6", filepath.Join(user, "go1.22.6"))
+}
+
+func TestMigrateDeduped(t *testing.T) {
+	from, to := t.TempDir(), filepath.Join(t.TempDir(), "new")
+	files := map[string]string{"VERSION": "go1.99.1", "bin/go": "binary"}
+	v1 := writeVersion(t, from, "go1.99.1", files)
+	v2 := writeVersion(t, from, "go1.99.2", files)
+	for _, dir := range []string{v1, v2} {
+		if err := writeReceipt(&Receipt{Version: filepath.Base(dir), Root: dir}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := dedupe(dir, "hardlink"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := readManifest(v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blob string
+	for _, e := range list {
+		if e.rel == "bin/go" {
+			blob = filepath.Join(storeDir, filepath.FromSlash(e.blob()))
+		}
+	}
+
+	// Moving one version keeps the blobs the other uses.
+	if err := migrate(from, to, []string{"go1.99.1"}); err != nil {
+		t.Fatal(err)
+	}
+	moved := filepath.Join(to, "go1.99.1", "bin", "go")
+	if _, err := os.Stat(filepath.Join(to, blob)); err != nil {
+		t.Errorf("blob of bin/go not in the new store: %v", err)
+	} else if !sameFile(t, moved, filepath.Join(to, blob)) {
+		t.Errorf("moved bin/go is not linked to the new store")
+	}
+	if _, err := os.Stat(filepath.Join(from, blob)); err != nil {
+		t.Errorf("blob still used by go1.99.2 removed from the old store: %v", err)
+	}
+	if _, err := readManifest(filepath.Join(to, "go1.99.1")); err != nil {
+		t.Errorf("moved version has no manifest: %v", err)
+	}
+
+	// Moving the other frees the old store.
+	if err := migrate(from, to, []string{"go1.99.2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(from, blob)); !os.IsNotExist(err) {
+		t.Errorf("unused blob left in the old store: %v", err)
+	}
+	if !sameFile(t, moved, filepath.Join(to, "go1.99.2", "bin", "go")) {
+		t.Errorf("moved versions do not share bin/go")
+	}
+}
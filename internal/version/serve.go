@@ -0,0 +1,624 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A server serves release archives, their checksums and a release
+// index in the URL layout of the official download site: archives and
+// checksums under /go/, and the index at /go/index.json and at
+// /dl/?mode=json, as on go.dev.
+//
+// The files are kept in a directory laid out as by the mirror command.
+// Unless the server is read-only, archives missing from the directory
+// are fetched from an upstream source, and streamed to clients as they
+// arrive. An archive is only stored once it is verified against the
+// upstream checksum; if it fails verification, the responses streaming
+// it are cut short, so that clients do not take it for complete.
+type server struct {
+	in       *Installer
+	dir      string
+	upstream source // nil if read-only
+	logf     func(format string, args ...any)
+
+	mu       sync.Mutex
+	inflight map[string]*fetchCall // by archive name
+
+	indexMu   sync.Mutex
+	index     []byte // upstream index, cached
+	indexTime time.Time
+}
+
+// A fetchCall is an upstream fetch of an archive, shared by all the
+// requests that need it. The archive is written to a partial file,
+// which the requests read as it grows.
+type fetchCall struct {
+	mu      sync.Mutex
+	f       *os.File      // partial file, nil unless readable
+	n       int64         // bytes written to f
+	size    int64         // of the archive, or -1 if unknown
+	done    bool          // whether the fetch is over
+	err     error         // of the fetch, once done
+	changed chan struct{} // closed when the fields above change
+}
+
+// update changes the fields of c with fn, and wakes the requests
+// waiting for them to change.
+func (c *fetchCall) update(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn()
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// readAt reads the bytes of the archive at off that have been written,
+// waiting for some to be if there are none. It returns 0 and
+// errFetchDone, or the error of the fetch, once the fetch is over.
+func (c *fetchCall) readAt(ctx context.Context, p []byte, off int64) (int, error) {
+	for {
+		c.mu.Lock()
+		switch {
+		case c.done:
+			err := c.err
+			c.mu.Unlock()
+			if err == nil {
+				err = errFetchDone
+			}
+			return 0, err
+		case c.f != nil && c.n > off:
+			if int64(len(p)) > c.n-off {
+				p = p[:c.n-off]
+			}
+			n, err := c.f.ReadAt(p, off)
+			c.mu.Unlock()
+			return n, err
+		}
+		changed := c.changed
+		c.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// errFetchDone reports that a fetch completed, and the archive is in
+// the directory.
+var errFetchDone = errors.New("fetch done")
+
+// indexTTL is how long a server caches the upstream release index.
+const indexTTL = 10 * time.Minute
+
+func newServer(in *Installer, dir string, upstream source) *server {
+	return &server{in: in, dir: dir, upstream: upstream, logf: in.logf, inflight: map[string]*fetchCall{}}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch {
+	case r.URL.Path == "/go/"+indexFile, r.URL.Path == "/dl/" && r.URL.Query().Get("mode") == "json":
+		s.serveIndex(w, r)
+	case strings.HasPrefix(r.URL.Path, "/go/"):
+		name := strings.TrimPrefix(r.URL.Path, "/go/")
+		if archive := strings.TrimSuffix(name, ".sha256"); archive != name {
+			s.serveChecksum(w, r, archive)
+		} else {
+			s.serveArchive(w, r, name)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// validName reports whether name is the name of a release archive.
+func validName(name string) bool {
+	f, ok := parseArchiveName(name)
+	return ok && f.Kind != "installer" && !strings.ContainsAny(name, `/\`)
+}
+
+// cached reports whether the named archive is in the directory.
+func (s *server) cached(name string) bool {
+	if _, err := os.Stat(filepath.Join(s.dir, name+".sha256")); err != nil {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(s.dir, name))
+	return err == nil
+}
+
+func (s *server) serveArchive(w http.ResponseWriter, r *http.Request, name string) {
+	if !validName(name) {
+		http.NotFound(w, r)
+		return
+	}
+	if !s.cached(name) {
+		if s.upstream == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == "HEAD" {
+			// Answer from upstream rather than fetching the
+			// archive just to report its size.
+			size, err := s.upstream.stat(r.Context(), name)
+			if err != nil {
+				s.upstreamError(w, r, err)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+			w.Header().Set("Accept-Ranges", "bytes")
+			return
+		}
+		if !s.streamArchive(w, r, name) {
+			return
+		}
+	}
+	f, err := os.Open(filepath.Join(s.dir, name))
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, "", fi.ModTime(), f)
+}
+
+// streamArchive serves the named archive, which is not cached, as it is
+// fetched from upstream. A request for a single range of the archive is
+// answered from the partial file too; other range requests wait for the
+// archive to be cached. If the fetch completes before any of it is
+// served, streamArchive instead reports that the caller should serve
+// the archive from the directory.
+func (s *server) streamArchive(w http.ResponseWriter, r *http.Request, name string) (serveCached bool) {
+	c := s.fetch(name)
+	buf := make([]byte, 32*1024)
+	start, end, ranged := int64(0), int64(-1), false
+	if h := r.Header.Get("Range"); h != "" {
+		// Wait for the fetch to learn the size of the archive.
+		_, err := c.readAt(r.Context(), buf[:0], 0)
+		c.mu.Lock()
+		size := c.size
+		c.mu.Unlock()
+		if err == nil && r.Header.Get("If-Range") == "" {
+			start, end, ranged = parseRange(h, size)
+		}
+		if !ranged {
+			// Leave the range to http.ServeContent.
+			_, err = c.readAt(r.Context(), buf, 1<<62)
+		}
+		if err == errFetchDone {
+			return true
+		}
+		if err != nil {
+			if r.Context().Err() == nil {
+				s.upstreamError(w, r, err)
+			}
+			return false
+		}
+	}
+	off := start
+	for off != end {
+		p := buf
+		if end >= 0 && int64(len(p)) > end-off {
+			p = p[:end-off]
+		}
+		n, err := c.readAt(r.Context(), p, off)
+		if err == errFetchDone && off == start {
+			return true
+		}
+		if err != nil && off == start {
+			if r.Context().Err() == nil {
+				s.upstreamError(w, r, err)
+			}
+			return false
+		}
+		if err == errFetchDone {
+			// Serve the rest from the directory.
+			f, err := os.Open(filepath.Join(s.dir, name))
+			if err != nil {
+				s.logf("%s: %v", r.URL.Path, err)
+				panic(http.ErrAbortHandler)
+			}
+			defer f.Close()
+			rest := int64(1 << 62)
+			if end >= 0 {
+				rest = end - off
+			}
+			if _, err := io.Copy(w, io.NewSectionReader(f, off, rest)); err != nil {
+				panic(http.ErrAbortHandler)
+			}
+			return false
+		}
+		if err != nil {
+			// Cut the response short, so that the client
+			// sees it fail.
+			if r.Context().Err() == nil {
+				s.logf("%s: %v", r.URL.Path, err)
+			}
+			panic(http.ErrAbortHandler)
+		}
+		if off == start {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			c.mu.Lock()
+			size := c.size
+			c.mu.Unlock()
+			if ranged {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, size))
+				w.Header().Set("Content-Length", strconv.FormatInt(end-start, 10))
+				w.WriteHeader(http.StatusPartialContent)
+			} else if size >= 0 {
+				w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+			}
+		}
+		if _, err := w.Write(buf[:n]); err != nil {
+			return false
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		off += int64(n)
+	}
+	return false
+}
+
+// parseRange parses h, the Range header of a request for an archive of
+// the given size, or -1 if unknown, as a single satisfiable range from
+// start up to end. It reports false for anything else, such as several
+// ranges, which are left to http.ServeContent once the archive is
+// cached.
+func parseRange(h string, size int64) (start, end int64, ok bool) {
+	if size <= 0 || !strings.HasPrefix(h, "bytes=") {
+		return 0, 0, false
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(strings.TrimPrefix(h, "bytes=")), "-")
+	if !found || strings.Contains(last, ",") {
+		return 0, 0, false
+	}
+	if first == "" {
+		// A suffix of the archive.
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size, true
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+	end = size
+	if last != "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < start {
+			return 0, 0, false
+		}
+		if n < size-1 {
+			end = n + 1
+		}
+	}
+	return start, end, true
+}
+
+func (s *server) serveChecksum(w http.ResponseWriter, r *http.Request, name string) {
+	if !validName(name) {
+		http.NotFound(w, r)
+		return
+	}
+	var sum string
+	if s.cached(name) {
+		data, err := os.ReadFile(filepath.Join(s.dir, name+".sha256"))
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		sum = string(data)
+	} else if s.upstream != nil {
+		var err error
+		if sum, err = s.upstream.checksum(r.Context(), name); err != nil {
+			s.upstreamError(w, r, err)
+			return
+		}
+	} else {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	http.ServeContent(w, r, "", time.Time{}, strings.NewReader(strings.TrimSpace(sum)))
+}
+
+func (s *server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	var data []byte
+	var err error
+	if s.upstream == nil {
+		data, err = os.ReadFile(filepath.Join(s.dir, indexFile))
+		if os.IsNotExist(err) {
+			// Synthesize an index from the files present.
+			var list []release
+			if list, err = (&fileSource{dir: s.dir}).list(r.Context()); err == nil {
+				data, err = json.Marshal(list)
+			}
+		}
+	} else {
+		data, err = s.upstreamIndex(r.Context())
+	}
+	if err != nil {
+		s.upstreamError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	http.ServeContent(w, r, "", time.Time{}, strings.NewReader(string(data)))
+}
+
+// upstreamIndex returns the release index of the upstream source,
+// caching it for indexTTL.
+func (s *server) upstreamIndex(ctx context.Context) ([]byte, error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	if s.index != nil && time.Since(s.indexTime) < indexTTL {
+		return s.index, nil
+	}
+	list, err := s.upstream.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	s.index, s.indexTime = data, time.Now()
+	return data, nil
+}
+
+// fetch starts fetching the named archive from upstream into the
+// directory, unless it is already being fetched, and returns the fetch.
+// The fetch is not tied to any one request, so that it is not wasted
+// if the client that started it goes away.
+func (s *server) fetch(name string) *fetchCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.inflight[name]
+	if c == nil {
+		c = &fetchCall{size: -1, changed: make(chan struct{})}
+		s.inflight[name] = c
+		go func() {
+			err := s.doFetch(c, name)
+			s.mu.Lock()
+			delete(s.inflight, name)
+			s.mu.Unlock()
+			c.update(func() {
+				if c.f != nil {
+					c.f.Close()
+					c.f = nil
+				}
+				c.done, c.err = true, err
+			})
+		}()
+	}
+	return c
+}
+
+// doFetch fetches the named archive for c. The download goes to a
+// partial file first, which is kept if the download fails so that the
+// next fetch resumes it, and is only moved into place once it matches
+// the upstream checksum.
+func (s *server) doFetch(c *fetchCall, name string) error {
+	ctx := context.Background()
+	if s.in.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.in.Timeout)
+		defer cancel()
+	}
+	if s.cached(name) {
+		return nil
+	}
+	sum, err := s.upstream.checksum(ctx, name)
+	if err != nil {
+		return err
+	}
+	s.logf("fetching %s", s.upstream.url(name))
+	dst := filepath.Join(s.dir, name)
+	partial := dst + ".partial"
+	f, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if f != nil {
+			c.update(func() { c.f = nil })
+			f.Close()
+		}
+	}()
+	hash := sha256.New()
+	offset, err := io.Copy(hash, f)
+	if err != nil {
+		return err
+	}
+	open := s.in.limitOpen(func(ctx context.Context, offset, length int64) (io.ReadCloser, int64, int64, error) {
+		return s.upstream.open(ctx, name, offset, length)
+	})
+	r, start, size, err := open(ctx, offset, -1)
+	if offset > 0 && err == errBadRange {
+		// The partial file is no prefix of the archive. Start over.
+		offset = 0
+		r, start, size, err = open(ctx, offset, -1)
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if start == 0 {
+		// The source ignored the offset, or there was none to
+		// resume from.
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		hash.Reset()
+	} else if offset > 0 {
+		s.logf("resuming %s at %s", name, fmtSize(offset))
+	}
+	if size != -1 {
+		size += start
+	}
+	c.update(func() { c.f, c.n, c.size = f, start, size })
+	n := start
+	buf := make([]byte, 32*1024)
+	for {
+		k, err := r.Read(buf)
+		if k > 0 {
+			if _, err := f.WriteAt(buf[:k], n); err != nil {
+				return err
+			}
+			hash.Write(buf[:k])
+			n += int64(k)
+			c.update(func() { c.n = n })
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if size != -1 && size != n {
+		return &NetworkError{URL: s.upstream.url(name), Err: fmt.Errorf("copied %v bytes; expected %v", n, size)}
+	}
+	c.update(func() { c.f = nil })
+	err = f.Close()
+	f = nil
+	if err != nil {
+		return err
+	}
+	if got := fmt.Sprintf("%x", hash.Sum(nil)); got != strings.TrimSpace(sum) {
+		os.Remove(partial)
+		return &ChecksumError{File: dst, Want: sum, Got: got}
+	}
+	if err := os.Rename(partial, dst); err != nil {
+		return err
+	}
+	// Write the checksum last: it marks the archive as cached.
+	if err := os.WriteFile(dst+".sha256", []byte(sum), 0644); err != nil {
+		return err
+	}
+	fi, _ := parseArchiveName(name)
+	fi.SHA256, fi.Size = sum, n
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return updateIndex(filepath.Join(s.dir, indexFile), []releaseFile{fi})
+}
+
+// upstreamError reports err, a failure to serve a request from
+// upstream, to the client.
+func (s *server) upstreamError(w http.ResponseWriter, r *http.Request, err error) {
+	var cerr *ChecksumError
+	switch {
+	case errors.Is(err, ErrNotFound), os.IsNotExist(err):
+		http.NotFound(w, r)
+	case errors.As(err, &cerr):
+		s.logf("%s: %v", r.URL.Path, err)
+		http.Error(w, "upstream archive failed checksum verification", http.StatusBadGateway)
+	default:
+		var nerr *NetworkError
+		if errors.As(err, &nerr) && nerr.StatusCode == http.StatusNotFound {
+			http.NotFound(w, r)
+			return
+		}
+		s.logf("%s: %v", r.URL.Path, err)
+		http.Error(w, "upstream error", http.StatusBadGateway)
+	}
+}
+
+// logRequests returns a handler that logs each request served by h in
+// the Common Log Format, followed by the request duration.
+func logRequests(h http.Handler, logf func(format string, args ...any)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rec, r)
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		logf("%s - - [%s] %q %d %d %v", host, start.Format("02/Jan/2006:15:04:05 -0700"),
+			r.Method+" "+r.URL.RequestURI()+" "+r.Proto, rec.status, rec.n, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// A statusRecorder records the status and size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	n      int64
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(p)
+	r.n += int64(n)
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// serve serves the directory dir on addr until ctx is canceled.
+func (s *server) serve(ctx context.Context, addr string) error {
+	// There is no write timeout: archives are large, and clients on
+	// slow links take long to download them.
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           logRequests(s, s.logf),
+		ReadHeaderTimeout: 30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	mode := "read-only"
+	if s.upstream != nil {
+		mode = "caching archives from " + s.upstream.url("")
+	}
+	s.logf("serving %s on %s, %s", s.dir, addr, mode)
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testServer starts a server for dir, with the given upstream, behind
+// httptest, recording its log.
+func testServer(t *testing.T, dir string, upstream source) (*httptest.Server, *strings.Builder) {
+	var mu sync.Mutex
+	var log strings.Builder
+	logf := func(format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(&log, format+"\n", args...)
+	}
+	in := &Installer{Progress: quietProgress{}, Logf: logf}
+	srv := httptest.NewServer(logRequests(newServer(in, dir, upstream), logf))
+	t.Cleanup(srv.Close)
+	return srv, &log
+}
+
+func TestServeCaching(t *testing.T) {
+	up := newFakeUpstream(t, []string{"go1.22.0"}, []platform{hostPlatform()})
+	upSrv := httptest.NewServer(up)
+	defer upSrv.Close()
+	in := &Installer{Progress: quietProgress{}, Logf: t.Logf}
+	upstream, err := in.newSource(upSrv.URL + "/go/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := t.TempDir()
+	srv, log := testServer(t, cache, upstream)
+
+	// Install through the server twice: the archive is fetched from
+	// upstream only once.
+	in.BaseURL, in.ChecksumURL = srv.URL+"/go/", ChecksumFromMirror
+	for i := 0; i < 2; i++ {
+		target := filepath.Join(t.TempDir(), "go1.22.0")
+		if err := in.Install(context.Background(), target, "go1.22.0"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	name := archiveName("go1.22.0")
+	if up.downloads[name] != 1 {
+		t.Errorf("upstream served %s %d times; want once", name, up.downloads[name])
+	}
+	if !(&server{dir: cache}).cached(name) {
+		t.Errorf("%s not cached", name)
+	}
+
+	// Ranges are served from the cache.
+	req, _ := http.NewRequest("GET", srv.URL+"/go/"+name, nil)
+	req.Header.Set("Range", "bytes=10-19")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusPartialContent || string(body) != string(up.files[name][10:20]) {
+		t.Errorf("range request = %v, %q; want 206 and bytes 10-19", res.Status, body)
+	}
+
+	// The index is served at both URLs.
+	for _, path := range []string{"/go/index.json", "/dl/?mode=json&include=all"} {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK || !strings.Contains(string(body), name) {
+			t.Errorf("GET %s = %v, %.100q; want index listing %s", path, res.Status, body, name)
+		}
+	}
+
+	for _, path := range []string{"/go/" + name, "/go/" + name + ".sha256", "/go/missing.linux-amd64.tar.gz", "/go/../secret"} {
+		res, err := http.Head(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	if !strings.Contains(log.String(), `"GET /go/`+name+` HTTP/1.1" 200`) {
+		t.Errorf("access log lacks archive download:\n%s", log)
+	}
+}
+
+func TestServeCorruptUpstream(t *testing.T) {
+	up := newFakeUpstream(t, []string{"go1.22.0"}, []platform{hostPlatform()})
+	name := archiveName("go1.22.0")
+	up.files[name+".sha256"] = []byte(strings.Repeat("0", 64))
+	upSrv := httptest.NewServer(up)
+	defer upSrv.Close()
+	in := &Installer{Progress: quietProgress{}, Logf: t.Logf}
+	upstream, err := in.newSource(upSrv.URL + "/go/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := t.TempDir()
+	srv, _ := testServer(t, cache, upstream)
+
+	// The archive is streamed as it arrives, so the failure is either
+	// reported up front or cuts the response short.
+	res, err := http.Get(srv.URL + "/go/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadGateway && err == nil {
+		t.Errorf("GET of corrupt archive = %v and a complete body; want 502 or a failed read", res.Status)
+	}
+	if _, err := os.Stat(filepath.Join(cache, name)); !os.IsNotExist(err) {
+		t.Errorf("corrupt archive was cached")
+	}
+}
+
+func TestServeReadOnly(t *testing.T) {
+	up := newFakeUpstream(t, []string{"go1.21.0", "go1.22.0"}, []platform{hostPlatform()})
+	upSrv := httptest.NewServer(up)
+	defer upSrv.Close()
+	in := &Installer{Progress: quietProgress{}, Logf: t.Logf}
+	upstream, err := in.newSource(upSrv.URL + "/go/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mirrorDir := t.TempDir()
+	versions, _ := parseVersionFilter("1.22")
+	if err := in.mirror(context.Background(), upstream, mirrorDir, &mirrorConfig{versions: versions}); err != nil {
+		t.Fatal(err)
+	}
+	srv, _ := testServer(t, mirrorDir, nil)
+
+	in.BaseURL, in.ChecksumURL = srv.URL+"/go/", ChecksumFromMirror
+	if err := in.Install(context.Background(), filepath.Join(t.TempDir(), "go1.22.0"), "go1.22.0"); err != nil {
+		t.Fatal(err)
+	}
+	err = in.Install(context.Background(), filepath.Join(t.TempDir(), "go1.21.0"), "go1.21.0")
+	if exitCode(err) != exitNotFound {
+		t.Errorf("Install of version missing from read-only server = %v; want not found", err)
+	}
+	if up.downloads[archiveName("go1.21.0")] != 0 {
+		t.Errorf("read-only server fetched from upstream")
+	}
+}
+
+// A stallingSource is a source whose archives stop after half their
+// bytes until release is closed.
+type stallingSource struct {
+	source
+	release chan struct{}
+}
+
+func (s *stallingSource) open(ctx context.Context, name string, offset, length int64) (io.ReadCloser, int64, int64, error) {
+	r, start, size, err := s.source.open(ctx, name, offset, length)
+	if err != nil {
+		return r, start, size, err
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	half := len(data) / 2
+	return io.NopCloser(io.MultiReader(
+		bytes.NewReader(data[:half]),
+		readerFunc(func(p []byte) (int, error) {
+			<-s.release
+			return 0, io.EOF
+		}),
+		bytes.NewReader(data[half:]),
+	)), start, size, nil
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+func TestServeStreaming(t *testing.T) {
+	up := newFakeUpstream(t, []string{"go1.22.0"}, []platform{hostPlatform()})
+	upSrv := httptest.NewServer(up)
+	defer upSrv.Close()
+	in := &Installer{Progress: quietProgress{}, Logf: t.Logf}
+	upstream, err := in.newSource(upSrv.URL + "/go/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stall := &stallingSource{upstream, make(chan struct{})}
+	cache := t.TempDir()
+	srv, _ := testServer(t, cache, stall)
+
+	// Two clients get the first half of the archive while upstream
+	// stalls, from one upstream download.
+	name := archiveName("go1.22.0")
+	want := up.files[name]
+	var bodies []io.ReadCloser
+	for i := 0; i < 2; i++ {
+		res, err := http.Get(srv.URL + "/go/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK || res.ContentLength != int64(len(want)) {
+			t.Fatalf("GET %s = %v with length %d; want 200 with length %d", name, res.Status, res.ContentLength, len(want))
+		}
+		half := make([]byte, len(want)/2)
+		if _, err := io.ReadFull(res.Body, half); err != nil || !bytes.Equal(half, want[:len(half)]) {
+			t.Fatalf("reading first half of %s while upstream stalls: %v", name, err)
+		}
+		bodies = append(bodies, res.Body)
+	}
+	if (&server{dir: cache}).cached(name) {
+		t.Errorf("%s cached before it was all fetched", name)
+	}
+
+	close(stall.release)
+	for _, body := range bodies {
+		rest, err := io.ReadAll(body)
+		if err != nil || !bytes.Equal(rest, want[len(want)/2:]) {
+			t.Errorf("reading rest of %s = %d bytes, %v; want %d bytes", name, len(rest), err, len(want)-len(want)/2)
+		}
+	}
+	if up.downloads[name] != 1 {
+		t.Errorf("upstream served %s %d times; want once", name, up.downloads[name])
+	}
+	if !(&server{dir: cache}).cached(name) {
+		t.Errorf("%s not cached after it was fetched", name)
+	}
+}
+
+func TestServeStreamingRange(t *testing.T) {
+	up := newFakeUpstream(t, []string{"go1.22.0"}, []platform{hostPlatform()})
+	upSrv := httptest.NewServer(up)
+	defer upSrv.Close()
+	in := &Installer{Progress: quietProgress{}, Logf: t.Logf}
+	upstream, err := in.newSource(upSrv.URL + "/go/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stall := &stallingSource{upstream, make(chan struct{})}
+	cache := t.TempDir()
+	srv, _ := testServer(t, cache, stall)
+
+	name := archiveName("go1.22.0")
+	want := up.files[name]
+	get := func(rng string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest("GET", srv.URL+"/go/"+name, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Range", rng)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+	check := func(res *http.Response, start, end int) {
+		t.Helper()
+		wantRange := fmt.Sprintf("bytes %d-%d/%d", start, end-1, len(want))
+		if res.StatusCode != http.StatusPartialContent || res.Header.Get("Content-Range") != wantRange || res.ContentLength != int64(end-start) {
+			t.Fatalf("ranged GET = %v, Content-Range %q, length %d; want 206, %q, %d",
+				res.Status, res.Header.Get("Content-Range"), res.ContentLength, wantRange, end-start)
+		}
+		got, err := io.ReadAll(res.Body)
+		if err != nil || !bytes.Equal(got, want[start:end]) {
+			t.Errorf("ranged GET body = %d bytes, %v; want bytes %d-%d of the archive", len(got), err, start, end-1)
+		}
+	}
+
+	// A range of what has been fetched is served while upstream
+	// stalls.
+	half := len(want) / 2
+	check(get(fmt.Sprintf("bytes=10-%d", half-1)), 10, half)
+	if (&server{dir: cache}).cached(name) {
+		t.Fatalf("%s cached before it was all fetched", name)
+	}
+
+	// A range reaching past it is served as the rest arrives, and
+	// several ranges once the archive is cached.
+	tail := get(fmt.Sprintf("bytes=%d-", half-10))
+	multi := make(chan *http.Response, 1)
+	go func() {
+		req, _ := http.NewRequest("GET", srv.URL+"/go/"+name, nil)
+		req.Header.Set("Range", "bytes=0-1,4-5")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			res = nil
+		}
+		multi <- res
+	}()
+	close(stall.release)
+	check(tail, half-10, len(want))
+	if res := <-multi; res != nil {
+		defer res.Body.Close()
+		if ct := res.Header.Get("Content-Type"); res.StatusCode != http.StatusPartialContent || !strings.HasPrefix(ct, "multipart/byteranges") {
+			t.Errorf("GET with several ranges = %v, Content-Type %q; want 206 multipart/byteranges", res.Status, ct)
+		}
+	}
+	if up.downloads[name] != 1 {
+		t.Errorf("upstream served %s %d times; want once", name, up.downloads[name])
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// A shared SDK root is a system-wide directory, such as /opt/go-sdk,
+// that administrators provision once per machine. The GODL_SHAREDROOT
+// setting lists such roots, separated by filepath.ListSeparator.
+// Versions installed there are used in preference to those in the
+// per-user SDK root, and new versions are installed into the first
+// shared root if the user can write to it, which is only checked when a
+// version is installed nowhere yet.
+//
+// Directories created in a shared root have the set-group-ID bit set,
+// so that every file in it belongs to the root's group, and files get
+// the same group permissions as user permissions, subject to the umask.
+
+// sharedRoots returns the configured shared SDK roots.
+func sharedRoots() []string {
+	var roots []string
+	for _, dir := range filepath.SplitList(getenv("GODL_SHAREDROOT")) {
+		if dir != "" {
+			roots = append(roots, filepath.Clean(dir))
+		}
+	}
+	return roots
+}
+
+// installRoot returns the SDK root that new versions are installed in:
+// the first shared root if it is writable, and sdkRoot otherwise.
+func installRoot() (string, error) {
+	if roots := sharedRoots(); len(roots) > 0 && isWritableDir(roots[0]) {
+		return roots[0], nil
+	}
+	return sdkRoot()
+}
+
+// isSharedRoot reports whether dir is one of the shared SDK roots.
+func isSharedRoot(dir string) bool {
+	dir = filepath.Clean(dir)
+	for _, root := range sharedRoots() {
+		if root == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// isWritableDir reports whether the current user can create files in
+// the directory dir.
+func isWritableDir(dir string) bool {
+	f, err := os.CreateTemp(dir, ".godl-write-test-")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
+// mkdirVersion creates the version directory dir. If shared is true, dir
+// is in a shared root and gets group permissions and the set-group-ID
+// bit, which directories created below it inherit.
+func mkdirVersion(dir string, shared bool) error {
+	if err := os.MkdirAll(dir, groupPerm(0755, shared)); err != nil {
+		return err
+	}
+	if !shared {
+		return nil
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	// The permissions were already reduced by the umask in MkdirAll;
+	// only add the set-group-ID bit.
+	return os.Chmod(dir, fi.Mode().Perm()|fs.ModeSetgid)
+}
+
+// isGroupShared reports whether dir is a directory whose contents are
+// shared with its group, as marked by mkdirVersion.
+func isGroupShared(dir string) bool {
+	fi, err := os.Stat(dir)
+	return err == nil && fi.IsDir() && fi.Mode()&fs.ModeSetgid != 0
+}
+
+// groupPerm returns perm with the user permission bits copied to the
+// group bits if shared is true. As with any permissions passed to
+// os.OpenFile or os.Mkdir, the umask still applies.
+func groupPerm(perm fs.FileMode, shared bool) fs.FileMode {
+	if !shared {
+		return perm
+	}
+	return perm | (perm&0700)>>3
+}
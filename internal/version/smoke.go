@@ -0,0 +1,177 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// SmokeTests lists the checks accepted by the GODL_SMOKE_TEST setting,
+// from least to most thorough: none, running the installed go command
+// to check its version and platform, or also building a program.
+var SmokeTests = []string{"off", "version", "build"}
+
+// checkSmokeTest reports an error if test is not one of SmokeTests. The
+// empty test is "off".
+func checkSmokeTest(test string) error {
+	if test == "" {
+		return nil
+	}
+	for _, t := range SmokeTests {
+		if test == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown smoke test %q; want one of %s", test, strings.Join(SmokeTests, ", "))
+}
+
+// smokeTestSetting returns the GODL_SMOKE_TEST setting, which defaults
+// to "version".
+func smokeTestSetting() string {
+	if t := getenv("GODL_SMOKE_TEST"); t != "" {
+		return t
+	}
+	return "version"
+}
+
+// smokeCommand returns the go command installed in root with the given
+// arguments, run as runGo runs it but using only this toolchain, from
+// the root, so that no go.mod or GOTOOLCHAIN setting switches to
+// another. The go env file is ignored too: the go command treats a
+// variable set to the empty string in the environment as unset, so
+// clearing one does not override a value set with 'go env -w'.
+func smokeCommand(ctx context.Context, root string, args ...string) *exec.Cmd {
+	cmd := GoCommand(ctx, root, args...)
+	cmd.Dir = root
+	cmd.Env = dedupEnv(caseInsensitiveEnv, append(cmd.Env, "GOENV=off", "GOTOOLCHAIN=local", "GOFLAGS=", "GO111MODULE="))
+	return cmd
+}
+
+// smokeTest checks that the go command installed in root runs, reports
+// the given version, and is the toolchain for platform p installed in
+// root.
+func smokeTest(ctx context.Context, root, version string, p platform) error {
+	cmd := smokeCommand(ctx, root, "version")
+	out, err := cmd.Output()
+	if err != nil {
+		return smokeError(cmd, err)
+	}
+	// The output is "go version go1.22.5 linux/amd64".
+	f := strings.Fields(string(out))
+	if len(f) < 3 || f[0] != "go" || f[1] != "version" {
+		return fmt.Errorf("smoke test: %s version printed %q; want \"go version %s\"", cmd.Path, strings.TrimSpace(string(out)), version)
+	}
+	if f[2] != version {
+		return fmt.Errorf("smoke test: %s reports version %s; want %s", cmd.Path, f[2], version)
+	}
+
+	// GOOS and GOARCH are cleared, so that a cross-compilation setting
+	// in the environment does not hide the toolchain's own platform.
+	cmd = smokeCommand(ctx, root, "env", "GOROOT", "GOOS", "GOARCH")
+	cmd.Env = dedupEnv(caseInsensitiveEnv, append(cmd.Env, "GOOS=", "GOARCH="))
+	out, err = cmd.Output()
+	if err != nil {
+		return smokeError(cmd, err)
+	}
+	lines := strings.Split(strings.TrimRight(string(out), "\r\n"), "\n")
+	if len(lines) != 3 {
+		return fmt.Errorf("smoke test: go env GOROOT GOOS GOARCH printed %q; want three lines", out)
+	}
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	if !sameDir(lines[0], root) {
+		return fmt.Errorf("smoke test: go env reports GOROOT=%s; want %s", lines[0], root)
+	}
+	if goos, goarch := lines[1], lines[2]; goos != p.os || releaseArch(goos, goarch) != p.arch {
+		return fmt.Errorf("smoke test: toolchain is for %s/%s; want %s", goos, goarch, p)
+	}
+	return nil
+}
+
+// sameDir reports whether a and b name the same directory.
+func sameDir(a, b string) bool {
+	afi, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bfi, err := os.Stat(b)
+	return err == nil && os.SameFile(afi, bfi)
+}
+
+// smokeError describes the failure of cmd, a smoke test, with the
+// likely cause of failing to run it at all.
+func smokeError(cmd *exec.Cmd, err error) error {
+	var eerr *exec.ExitError
+	switch {
+	case errors.As(err, &eerr):
+		return fmt.Errorf("smoke test: %s failed: %v\n%s", strings.Join(cmd.Args, " "), err, eerr.Stderr)
+	case isExecFormatError(err):
+		return fmt.Errorf("smoke test: cannot run %s: %v; it is not built for this machine's architecture", cmd.Path, err)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("smoke test: cannot run %s: %v; is its file system mounted noexec?", cmd.Path, err)
+	}
+	return fmt.Errorf("smoke test: cannot run %s: %v", cmd.Path, err)
+}
+
+// runSmokeTests runs the checks that in.SmokeTest selects on the
+// version installed in dir from the archive for platform p, building a
+// program whatever it selects if in.Profile leaves out files.
+func (in *Installer) runSmokeTests(ctx context.Context, dir, version string, p platform) error {
+	if in.SmokeTest == "version" || in.SmokeTest == "build" {
+		in.logf("Checking that %s runs ...", version)
+		if err := smokeTest(ctx, dir, version, p); err != nil {
+			return err
+		}
+	}
+	switch {
+	case !in.Profile.full():
+		in.logf("Checking that profile %v can build programs ...", in.Profile)
+	case in.SmokeTest == "build":
+		in.logf("Checking that %s can build programs ...", version)
+	default:
+		return nil
+	}
+	return smokeBuild(ctx, dir)
+}
+
+// helloWorld is the program that smokeBuild builds.
+const helloWorld = `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("hello, world")
+}
+`
+
+// smokeBuild checks that the go command installed in root can build
+// programs.
+func smokeBuild(ctx context.Context, root string) error {
+	dir, err := os.MkdirTemp("", "godl-smoke-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "hello.go")
+	if err := os.WriteFile(src, []byte(helloWorld), 0644); err != nil {
+		return err
+	}
+	cmd := smokeCommand(ctx, root, "build", "-o", filepath.Join(dir, "hello"+exe()), src)
+	cmd.Dir = dir
+	// Build needing no C compiler.
+	cmd.Env = dedupEnv(caseInsensitiveEnv, append(cmd.Env, "CGO_ENABLED=0"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("smoke build of hello world failed: %v\n%s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix && !windows
+
+package version
+
+// isExecFormatError reports whether err is the error from running a
+// program built for another architecture, which no error on this
+// platform tells.
+func isExecFormatError(err error) bool {
+	return false
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeGo returns a shell script that acts as the go command of the
+// given version for platform p, as far as the smoke tests ask, and
+// then exits with the given status.
+func fakeGo(version string, p platform, status string) string {
+	goarch := p.arch
+	if goarch == "armv6l" {
+		goarch = "arm"
+	}
+	return "#!/bin/sh\n" +
+		"case $1 in\n" +
+		"version) echo go version " + version + " " + p.os + "/" + goarch + " ;;\n" +
+		"env) echo \"$GOROOT\"; echo " + p.os + "; echo " + goarch + " ;;\n" +
+		"esac\n" +
+		"exit " + status + "\n"
+}
+
+func TestInstallSmokeTest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake go command is a shell script")
+	}
+	tests := []struct {
+		name   string
+		goCmd  string
+		errMsg string // "" for success
+	}{
+		{"ok", fakeGo("go1.99.1", hostPlatform(), "0"), ""},
+		{"wrong version", fakeGo("go1.99.0", hostPlatform(), "0"), "reports version go1.99.0; want go1.99.1"},
+		{"wrong platform", fakeGo("go1.99.1", platform{"plan9", "386"}, "0"), "toolchain is for plan9/386"},
+		{"failure", fakeGo("go1.99.1", hostPlatform(), "3"), "exit status 3"},
+		{"not executable", "\x7fELF garbage", "not built for this machine's architecture"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := testArchive(t, map[string]string{"VERSION": "go1.99.1", "bin/go": tt.goCmd})
+			name := archiveName("go1.99.1")
+			if err := os.WriteFile(filepath.Join(dir, name), archive, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, name+".sha256"), []byte(sha256Hex(archive)), 0644); err != nil {
+				t.Fatal(err)
+			}
+			in := &Installer{BaseURL: fileURL(dir), ChecksumURL: ChecksumFromMirror, Progress: quietProgress{}, Logf: t.Logf, SmokeTest: "version"}
+			target := filepath.Join(t.TempDir(), "go1.99.1")
+			err := in.Install(context.Background(), target, "go1.99.1")
+			if tt.errMsg == "" {
+				if err != nil || !IsInstalled(target) {
+					t.Fatalf("Install = %v, installed %v; want success", err, IsInstalled(target))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("Install = %v; want error containing %q", err, tt.errMsg)
+			}
+			if IsInstalled(target) {
+				t.Errorf("version is installed after failed smoke test")
+			}
+			if _, err := os.Stat(filepath.Join(target, "bin")); err == nil {
+				t.Errorf("unpacked files left after failed smoke test")
+			}
+			if _, err := os.Stat(filepath.Join(target, name)); err != nil {
+				t.Errorf("archive removed after failed smoke test: %v", err)
+			}
+		})
+	}
+}
+
+func TestSmokeTestNoExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no execute permission")
+	}
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Even root cannot run a file without execute permission, as on a
+	// file system mounted noexec.
+	if err := os.WriteFile(filepath.Join(root, "bin", "go"), []byte(fakeGo("go1.99.1", hostPlatform(), "0")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := smokeTest(context.Background(), root, "go1.99.1", hostPlatform())
+	if err == nil || !strings.Contains(err.Error(), "noexec") {
+		t.Errorf("smokeTest = %v; want noexec diagnostic", err)
+	}
+}
+
+func TestSmokeTestSetting(t *testing.T) {
+	in := &Installer{SmokeTest: "thorough"}
+	if err := in.Install(context.Background(), t.TempDir(), "go1.99.1"); err == nil || !strings.Contains(err.Error(), "unknown smoke test") {
+		t.Errorf("Install with smoke test %q = %v; want error", in.SmokeTest, err)
+	}
+}
+
+func TestSmokeTestRealToolchain(t *testing.T) {
+	root, v := runtime.GOROOT(), runtime.Version()
+	if _, err := os.Stat(filepath.Join(root, "bin", "go"+exe())); err != nil || strings.ContainsAny(v, " ") || strings.HasPrefix(v, "devel") {
+		t.Skipf("no released go command in %s", root)
+	}
+	if err := smokeTest(context.Background(), root, v, hostPlatform()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSmokeTestGoEnvFile(t *testing.T) {
+	root, v := runtime.GOROOT(), runtime.Version()
+	if _, err := os.Stat(filepath.Join(root, "bin", "go"+exe())); err != nil || strings.ContainsAny(v, " ") || strings.HasPrefix(v, "devel") {
+		t.Skipf("no released go command in %s", root)
+	}
+	// A cross-compilation target set with 'go env -w' does not hide
+	// the toolchain's own platform.
+	other := "arm64"
+	if runtime.GOARCH == other {
+		other = "amd64"
+	}
+	goenv := filepath.Join(t.TempDir(), "env")
+	if err := os.WriteFile(goenv, []byte("GOOS=plan9\nGOARCH="+other+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOENV", goenv)
+	if err := smokeTest(context.Background(), root, v, hostPlatform()); err != nil {
+		t.Error(err)
+	}
+}
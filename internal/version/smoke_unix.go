@@ -0,0 +1,18 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package version
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isExecFormatError reports whether err is the error from running a
+// program built for another architecture.
+func isExecFormatError(err error) bool {
+	return errors.Is(err, syscall.ENOEXEC)
+}
@@ -0,0 +1,22 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Errors from CreateProcess for programs built for another architecture.
+const (
+	errorBadExeFormat           = syscall.Errno(193) // ERROR_BAD_EXE_FORMAT
+	errorExeMachineTypeMismatch = syscall.Errno(216) // ERROR_EXE_MACHINE_TYPE_MISMATCH
+)
+
+// isExecFormatError reports whether err is the error from running a
+// program built for another architecture.
+func isExecFormatError(err error) bool {
+	return errors.Is(err, errorBadExeFormat) || errors.Is(err, errorExeMachineTypeMismatch)
+}
@@ -0,0 +1,27 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSmokeTestBadExeFormat(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "bin", "go.exe"), []byte("\x7fELF garbage"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	err := smokeTest(context.Background(), root, "go1.99.1", hostPlatform())
+	if err == nil || !strings.Contains(err.Error(), "not built for this machine's architecture") {
+		t.Errorf("smokeTest = %v; want wrong architecture diagnostic", err)
+	}
+}
@@ -0,0 +1,420 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A source provides release archives and their SHA-256 checksums.
+// Archives are named as on the official download site, such as
+// "go1.22.5.linux-amd64.tar.gz", and each has a checksum in a file of
+// the same name with a ".sha256" suffix.
+type source interface {
+	// list returns the releases the source provides.
+	list(ctx context.Context) ([]release, error)
+
+	// stat returns the size of the named archive, or an error wrapping
+	// ErrNotFound if the source does not have it.
+	stat(ctx context.Context, name string) (int64, error)
+
+	// open opens the named archive for reading length bytes from
+	// offset, or through the end if length is negative. If the source
+	// cannot start at offset, it may start at 0 instead and read
+	// through the end; start reports which. size is the number of
+	// bytes that follow, or -1 if unknown.
+	open(ctx context.Context, name string, offset, length int64) (r io.ReadCloser, start, size int64, err error)
+
+	// checksum returns the hex SHA-256 checksum of the named archive.
+	checksum(ctx context.Context, name string) (string, error)
+
+	// url returns the URL of the named archive, for messages and
+	// receipts.
+	url(name string) string
+}
+
+// upstreamIndexURL is the URL of the release index of the official
+// download site.
+const upstreamIndexURL = "https://go.dev/dl/?mode=json&include=all"
+
+// indexFile is the name of the release index that other HTTP sources,
+// such as mirrors, serve alongside their archives.
+const indexFile = "index.json"
+
+// A release describes a Go release in the format of the release index
+// at upstreamIndexURL.
+type release struct {
+	Version string        `json:"version"`
+	Stable  bool          `json:"stable"`
+	Files   []releaseFile `json:"files"`
+}
+
+// A releaseFile describes one file of a release.
+type releaseFile struct {
+	Filename string `json:"filename"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Version  string `json:"version"`
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Kind     string `json:"kind"` // "archive", "installer" or "source"
+}
+
+// parseArchiveName parses a release file name such as
+// "go1.22.5.linux-amd64.tar.gz". It reports false for names that are
+// not release files.
+func parseArchiveName(name string) (f releaseFile, ok bool) {
+	var base string
+	for _, ext := range []string{".tar.gz", ".zip", ".msi", ".pkg"} {
+		if strings.HasSuffix(name, ext) {
+			base = strings.TrimSuffix(name, ext)
+			f.Kind = "archive"
+			if ext == ".msi" || ext == ".pkg" {
+				f.Kind = "installer"
+			}
+			break
+		}
+	}
+	i := strings.LastIndex(base, ".")
+	if !strings.HasPrefix(base, "go") || i < 0 {
+		return releaseFile{}, false
+	}
+	f.Filename, f.Version = name, base[:i]
+	if platform := base[i+1:]; platform == "src" {
+		f.Kind = "source"
+	} else if goos, goarch, ok := strings.Cut(platform, "-"); ok {
+		f.OS, f.Arch = goos, goarch
+	} else {
+		return releaseFile{}, false
+	}
+	return f, true
+}
+
+// groupReleases groups files into releases, newest first, as in the
+// upstream release index.
+func groupReleases(files []releaseFile) []release {
+	byVersion := map[string]*release{}
+	for _, f := range files {
+		r := byVersion[f.Version]
+		if r == nil {
+			v, ok := parseVersion(f.Version)
+			r = &release{Version: f.Version, Stable: !ok || v.kind != "beta" && v.kind != "rc"}
+			byVersion[f.Version] = r
+		}
+		r.Files = append(r.Files, f)
+	}
+	var list []release
+	for _, r := range byVersion {
+		list = append(list, *r)
+	}
+	sort.Slice(list, func(i, j int) bool { return cmpVersion(list[i].Version, list[j].Version) > 0 })
+	for _, r := range list {
+		sort.Slice(r.Files, func(i, j int) bool { return r.Files[i].Filename < r.Files[j].Filename })
+	}
+	return list
+}
+
+// newSource returns the source at rawURL, which is one of:
+//
+//   - an http or https URL of a directory laid out like the official
+//     download site;
+//   - a file URL of a local directory holding archives and checksums;
+//   - an s3+http or s3+https URL of a bucket, and optionally a key
+//     prefix, on an S3-compatible server, as in
+//     s3+https://host/bucket/prefix/.
+func (in *Installer) newSource(rawURL string) (source, error) {
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source URL %s: %v", redactURL(rawURL), err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		base := strings.TrimSuffix(rawURL, "/") + "/"
+		index := base + indexFile
+		if base == DefaultBaseURL {
+			index = upstreamIndexURL
+		}
+		return &httpSource{in: in, base: base, index: index}, nil
+	case "file":
+		dir := filePath(u)
+		if u.Host != "" && u.Host != "localhost" {
+			return nil, fmt.Errorf("%s: file URL with remote host", redactURL(rawURL))
+		}
+		return &fileSource{dir: dir}, nil
+	case "s3+http", "s3+https":
+		bucket, prefix, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("%s: missing bucket name", redactURL(rawURL))
+		}
+		if prefix != "" && !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		endpoint := strings.TrimPrefix(u.Scheme, "s3+") + "://" + u.Host + "/" + bucket
+		return &s3Source{
+			httpSource: httpSource{in: in, base: endpoint + "/" + prefix},
+			endpoint:   endpoint,
+			prefix:     prefix,
+		}, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported source URL scheme %q", redactURL(rawURL), u.Scheme)
+}
+
+// sources returns the mirrors to install version from, in the order
+// to try them: the sources listed in in.BaseURL if set, and otherwise
+// those configured by the first entry of the GODL_SOURCES setting whose
+// pattern matches version.
+//
+// GODL_SOURCES is a comma-separated list of pattern=URLs pairs, where
+// pattern is matched against the version as by path.Match and URLs is
+// one source URL or several separated by "|", such as
+//
+//	go*-acme=s3+https://minio.example.com/go-builds/,*=https://mirror.example.com/go/|https://dl.google.com/go/
+//
+// If no pattern matches, the official download site is used.
+func (in *Installer) sources(version string) ([]source, error) {
+	urls := in.BaseURL
+	if urls == "" {
+		urls = DefaultBaseURL
+		for _, entry := range strings.Split(getenv("GODL_SOURCES"), ",") {
+			pattern, rawURLs, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok {
+				continue
+			}
+			if matched, err := path.Match(pattern, version); err != nil {
+				return nil, fmt.Errorf("GODL_SOURCES: bad pattern %q: %v", pattern, err)
+			} else if matched {
+				urls = rawURLs
+				break
+			}
+		}
+	}
+	var list []source
+	for _, rawURL := range strings.Split(urls, "|") {
+		if rawURL = strings.TrimSpace(rawURL); rawURL == "" {
+			continue
+		}
+		src, err := in.newSource(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, src)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no sources configured for %s", version)
+	}
+	return list, nil
+}
+
+// httpSource is a directory on an HTTP server laid out like the official
+// download site.
+type httpSource struct {
+	in    *Installer
+	base  string // with trailing slash
+	index string // URL of the release index
+}
+
+// url returns the URL of the named file for messages and receipts,
+// without any credentials in s.base; fetch returns it for requests.
+func (s *httpSource) url(name string) string { return redactURL(s.base) + name }
+
+func (s *httpSource) fetch(name string) string { return s.base + name }
+
+func (s *httpSource) list(ctx context.Context) ([]release, error) {
+	data, err := s.in.slurpURLToString(ctx, s.index)
+	if err != nil {
+		return nil, err
+	}
+	var list []release
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", redactURL(s.index), err)
+	}
+	return list, nil
+}
+
+func (s *httpSource) stat(ctx context.Context, name string) (int64, error) {
+	res, err := s.in.get(ctx, s.in.client(), "HEAD", s.fetch(name), nil)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return 0, fmt.Errorf("%s: %w", s.url(name), ErrNotFound)
+	}
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("checking size: %w", &NetworkError{URL: s.url(name), StatusCode: res.StatusCode})
+	}
+	return res.ContentLength, nil
+}
+
+func (s *httpSource) open(ctx context.Context, name string, offset, length int64) (io.ReadCloser, int64, int64, error) {
+	return s.in.fetchRange(ctx, s.fetch(name), offset, length)
+}
+
+func (s *httpSource) checksum(ctx context.Context, name string) (string, error) {
+	sum, err := s.in.slurpURLToString(ctx, s.fetch(name)+".sha256")
+	return strings.TrimSpace(sum), err
+}
+
+// fileSource is a local directory holding archives and checksums.
+type fileSource struct {
+	dir string
+}
+
+func (s *fileSource) url(name string) string {
+	return fileURL(filepath.Join(s.dir, name))
+}
+
+// fileURL returns the file URL of the absolute path name, such as
+// file:///C:/go for C:\go on Windows.
+func fileURL(name string) string {
+	p := filepath.ToSlash(name)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// filePath returns the local path that the file URL u names, undoing
+// fileURL.
+func filePath(u *url.URL) string {
+	p := u.Path
+	if len(p) > 1 && p[0] == '/' && filepath.VolumeName(filepath.FromSlash(p[1:])) != "" {
+		// /C:/go on Windows.
+		p = p[1:]
+	}
+	return filepath.FromSlash(p)
+}
+
+func (s *fileSource) list(ctx context.Context) ([]release, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []releaseFile
+	for _, e := range entries {
+		f, ok := parseArchiveName(e.Name())
+		if !ok {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			f.Size = info.Size()
+		}
+		f.SHA256, _ = s.checksum(ctx, e.Name())
+		files = append(files, f)
+	}
+	return groupReleases(files), nil
+}
+
+func (s *fileSource) stat(ctx context.Context, name string) (int64, error) {
+	fi, err := os.Stat(filepath.Join(s.dir, name))
+	if os.IsNotExist(err) {
+		return 0, fmt.Errorf("%s: %w", s.url(name), ErrNotFound)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+func (s *fileSource) open(ctx context.Context, name string, offset, length int64) (io.ReadCloser, int64, int64, error) {
+	f, err := os.Open(filepath.Join(s.dir, name))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	fi, err := f.Stat()
+	if err == nil && offset > fi.Size() {
+		err = errBadRange
+	}
+	if err == nil {
+		_, err = f.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, 0, 0, err
+	}
+	size := fi.Size() - offset
+	if length >= 0 && length < size {
+		size = length
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f, size), f}, offset, size, nil
+}
+
+func (s *fileSource) checksum(ctx context.Context, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name+".sha256"))
+	return strings.TrimSpace(string(data)), err
+}
+
+// s3Source is a bucket on an S3-compatible server, addressed path-style.
+// It fetches objects as an httpSource does and lists them with the
+// ListObjectsV2 API.
+type s3Source struct {
+	httpSource
+	endpoint string // URL of the bucket
+	prefix   string // key prefix of the archives
+}
+
+// s3ListResult is the part of a ListObjectsV2 response that s3Source
+// uses.
+type s3ListResult struct {
+	Contents []struct {
+		Key  string
+		Size int64
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+func (s *s3Source) list(ctx context.Context) ([]release, error) {
+	var files []releaseFile
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {s.prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		data, err := s.in.slurpURLToString(ctx, s.endpoint+"?"+q.Encode())
+		if err != nil {
+			return nil, err
+		}
+		var res s3ListResult
+		if err := xml.Unmarshal([]byte(data), &res); err != nil {
+			return nil, fmt.Errorf("parsing bucket listing of %s: %v", redactURL(s.endpoint), err)
+		}
+		for _, obj := range res.Contents {
+			name := strings.TrimPrefix(obj.Key, s.prefix)
+			if strings.Contains(name, "/") {
+				continue
+			}
+			if f, ok := parseArchiveName(name); ok {
+				f.Size = obj.Size
+				files = append(files, f)
+			}
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			break
+		}
+		token = res.NextContinuationToken
+	}
+	return groupReleases(files), nil
+}
+
+// errBadRange reports that a source cannot start reading an archive at
+// the requested offset, because the partial file is not a prefix of it.
+var errBadRange = errors.New("requested range not satisfiable")
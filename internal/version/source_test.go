@@ -0,0 +1,272 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testArchive returns an archive of a fake Go release for the host, in
+// the host's archive format, holding the given files below "go/".
+func testArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	if getOS() == "windows" {
+		zw := zip.NewWriter(&buf)
+		for _, name := range names {
+			w, err := zw.Create("go/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte(files[name]))
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, name := range names {
+		hdr := &tar.Header{Name: "go/" + name, Mode: 0644, Size: int64(len(files[name]))}
+		if strings.HasPrefix(name, "bin/") {
+			hdr.Mode = 0755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(files[name]))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sha256Hex(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+func TestParseArchiveName(t *testing.T) {
+	tests := []struct {
+		name string
+		want releaseFile
+		ok   bool
+	}{
+		{"go1.22.5.linux-amd64.tar.gz", releaseFile{Version: "go1.22.5", OS: "linux", Arch: "amd64", Kind: "archive"}, true},
+		{"go1.21rc2.windows-arm64.zip", releaseFile{Version: "go1.21rc2", OS: "windows", Arch: "arm64", Kind: "archive"}, true},
+		{"go1.22.5.darwin-arm64.pkg", releaseFile{Version: "go1.22.5", OS: "darwin", Arch: "arm64", Kind: "installer"}, true},
+		{"go1.22.5.src.tar.gz", releaseFile{Version: "go1.22.5", Kind: "source"}, true},
+		{"go1.22.5.linux-amd64.tar.gz.sha256", releaseFile{}, false},
+		{"index.json", releaseFile{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseArchiveName(tt.name)
+		if tt.ok {
+			tt.want.Filename = tt.name
+		}
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseArchiveName(%q) = %+v, %v; want %+v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	archive := testArchive(t, map[string]string{"VERSION": "go1.99.1"})
+	dir := t.TempDir()
+	name := archiveName("go1.99.1")
+	if err := os.WriteFile(filepath.Join(dir, name), archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".sha256"), []byte(sha256Hex(archive)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	in := &Installer{BaseURL: fileURL(dir), ChecksumURL: ChecksumFromMirror, Progress: quietProgress{}, Logf: t.Logf}
+	srcs, err := in.sources("go1.99.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := srcs[0].list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Version != "go1.99.1" || list[0].Files[0].SHA256 != sha256Hex(archive) {
+		t.Errorf("list = %+v; want go1.99.1 with its checksum", list)
+	}
+
+	target := filepath.Join(t.TempDir(), "go1.99.1")
+	if err := in.Install(context.Background(), target, "go1.99.1"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(target, "VERSION")); err != nil || string(data) != "go1.99.1" {
+		t.Errorf("VERSION = %q, %v; want go1.99.1", data, err)
+	}
+}
+
+// s3StandIn is a minimal stand-in for an S3-compatible server holding
+// one bucket. It supports GET and HEAD of objects, with ranges, and
+// ListObjectsV2 with a page size of one, to exercise continuation.
+type s3StandIn struct {
+	bucket  string
+	objects map[string][]byte
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.bucket {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	if key != "" {
+		obj, ok := s.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(obj))
+		return
+	}
+	if r.URL.Query().Get("list-type") != "2" {
+		http.Error(w, "unsupported", http.StatusNotImplemented)
+		return
+	}
+	var keys []string
+	for k := range s.objects {
+		if strings.HasPrefix(k, r.URL.Query().Get("prefix")) && k > r.URL.Query().Get("continuation-token") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var res s3ListResult
+	if len(keys) > 0 {
+		res.Contents = append(res.Contents, struct {
+			Key  string
+			Size int64
+		}{keys[0], int64(len(s.objects[keys[0]]))})
+		res.IsTruncated = len(keys) > 1
+		res.NextContinuationToken = keys[0]
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"ListBucketResult"`
+		s3ListResult
+	}{s3ListResult: res})
+}
+
+func TestFileURL(t *testing.T) {
+	// A recorded file URL, such as a receipt's mirror, names the same
+	// directory again.
+	dir := t.TempDir()
+	u := fileURL(dir)
+	if !strings.HasPrefix(u, "file:///") {
+		t.Errorf("fileURL(%q) = %q; want file:/// prefix", dir, u)
+	}
+	src, err := (&Installer{}).newSource(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := src.(*fileSource).dir; got != dir {
+		t.Errorf("newSource(%q) has directory %q; want %q", u, got, dir)
+	}
+	if got := src.url(""); got != u {
+		t.Errorf("source URL = %q; want %q", got, u)
+	}
+
+	if got, want := fileURL("C:/go/dl"), "file:///C:/go/dl"; got != want {
+		t.Errorf("fileURL of a drive path = %q; want %q", got, want)
+	}
+	if runtime.GOOS == "windows" {
+		src, err := (&Installer{}).newSource("file:///C:/go/dl")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := src.(*fileSource).dir, `C:\go\dl`; got != want {
+			t.Errorf("newSource of a drive URL has directory %q; want %q", got, want)
+		}
+	}
+}
+
+func TestS3Source(t *testing.T) {
+	acme := testArchive(t, map[string]string{"VERSION": "go1.99.1-acme"})
+	name := archiveName("go1.99.1-acme")
+	s3 := &s3StandIn{bucket: "builds", objects: map[string][]byte{
+		"go/" + name:                         acme,
+		"go/" + name + ".sha256":             []byte(sha256Hex(acme)),
+		"go/" + archiveName("go1.99.2-acme"): acme,
+		"other/" + archiveName("go1.99.3"):   acme,
+		"go/README.txt":                      []byte("not a release"),
+	}}
+	srv := httptest.NewServer(s3)
+	defer srv.Close()
+	s3URL := "s3+" + srv.URL + "/builds/go"
+
+	// Private builds come from the bucket and everything else from
+	// upstream.
+	t.Setenv("GODL_CONFIG", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("GODL_SOURCES", "go*-acme="+s3URL+", *=https://dl.google.com/go/")
+	t.Setenv("GODL_CHECKSUM_SOURCE", ChecksumFromMirror)
+	in := &Installer{Progress: quietProgress{}, Logf: t.Logf}
+	if srcs, err := in.sources("go1.22.5"); err != nil || len(srcs) != 1 || srcs[0].url("x") != DefaultBaseURL+"x" {
+		t.Errorf("sources(go1.22.5) = %v, %v; want upstream", srcs, err)
+	}
+	srcs, err := in.sources("go1.99.1-acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, ok := srcs[0].(*s3Source)
+	if len(srcs) != 1 || !ok {
+		t.Fatalf("sources(go1.99.1-acme) = %T; want one *s3Source", srcs)
+	}
+
+	list, err := src.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []string
+	for _, r := range list {
+		versions = append(versions, r.Version)
+	}
+	if got, want := strings.Join(versions, " "), "go1.99.2-acme go1.99.1-acme"; got != want {
+		t.Errorf("listed versions %q; want %q", got, want)
+	}
+
+	target := filepath.Join(t.TempDir(), "go1.99.1-acme")
+	if err := in.Install(context.Background(), target, "go1.99.1-acme"); err != nil {
+		t.Fatal(err)
+	}
+	r, err := ReadReceipt(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := srv.URL + "/builds/go/" + name; r.URL != want {
+		t.Errorf("receipt URL = %q; want %q", r.URL, want)
+	}
+}
@@ -6,11 +6,10 @@
 package version
 
 import (
-	"archive/tar"
-	"archive/zip"
-	"compress/gzip"
+	"context"
 	"crypto/sha256"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -19,7 +18,6 @@ import (
 	"os/exec"
 	"os/signal"
 	"os/user"
-	"path"
 	"path/filepath"
 	"runtime"
 	"strconv"
@@ -27,44 +25,95 @@ import (
 	"time"
 )
 
-func init() {
-	http.DefaultTransport = &userAgentTransport{http.DefaultTransport}
-}
-
 // Run runs the "go" tool of the provided Go version.
 func Run(version string) {
 	log.SetFlags(0)
 
-	root, err := goroot(version)
+	root, err := Goroot(version)
 	if err != nil {
-		log.Fatalf("%s: %v", version, err)
-	}
-
-	if len(os.Args) == 2 && os.Args[1] == "download" {
-		if err := install(root, version); err != nil {
-			log.Fatalf("%s: download failed: %v", version, err)
+		fatal(version, err)
+	}
+
+	if len(os.Args) >= 2 && os.Args[1] == "download" {
+		in := newInstaller()
+		fs := flag.NewFlagSet(version+" download", flag.ExitOnError)
+		fs.DurationVar(&in.Timeout, "timeout", in.Timeout, "give up if the whole install takes longer than `duration` (0 for no limit)")
+		fs.DurationVar(&in.IdleTimeout, "idle-timeout", in.IdleTimeout, "give up if no data arrives for `duration` (0 for no limit)")
+		progress := fs.String("progress", getenv("GODL_PROGRESS"), "progress output `mode`: "+strings.Join(ProgressModes, ", "))
+		fs.StringVar(&in.Profile.Name, "profile", in.Profile.Name, "files to install: "+strings.Join(ProfileNames, ", "))
+		include := fs.String("include", strings.Join(in.Profile.Include, ","), "install only files matching these comma-separated `patterns`")
+		exclude := fs.String("exclude", strings.Join(in.Profile.Exclude, ","), "leave out files matching these comma-separated `patterns`")
+		fs.Parse(os.Args[2:])
+		if fs.NArg() > 0 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		step, _ := strconv.Atoi(getenv("GODL_PROGRESS_STEP"))
+		p, err := NewProgress(*progress, os.Stderr, step)
+		if err != nil {
+			log.Printf("%s download: %v", version, err)
+			os.Exit(2)
+		}
+		in.Progress = p
+		in.Profile.Include, in.Profile.Exclude = splitPatterns(*include), splitPatterns(*exclude)
+		if _, err := in.Profile.filter(); err != nil {
+			log.Printf("%s download: %v", version, err)
+			os.Exit(2)
+		}
+		if err := install(in, root, version); err != nil {
+			fatal(version+": download failed", err)
 		}
 		os.Exit(0)
 	}
 
-	if _, err := os.Stat(filepath.Join(root, unpackedOkay)); err != nil {
-		log.Fatalf("%s: not downloaded. Run '%s download' to install to %v", version, version, root)
+	if !IsInstalled(root) {
+		in := newInstaller()
+		if !in.getenvBool("GODL_AUTODOWNLOAD") {
+			log.Printf("%s: not downloaded. Run '%s download' to install to %v", version, version, root)
+			os.Exit(exitNotInstalled)
+		}
+		// All install output goes to stderr, so stdout is left
+		// to the go command.
+		log.Printf("%s: not downloaded; installing to %v", version, root)
+		if err := install(in, root, version); err != nil {
+			fatal(version+": download failed", err)
+		}
 	}
 
 	runGo(root)
 }
 
-func runGo(root string) {
-	gobin := filepath.Join(root, "bin", "go"+exe())
-	cmd := exec.Command(gobin, os.Args[1:]...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// GoCommand returns a command that runs the go tool installed in root
+// with the given arguments, with GOROOT and PATH set to use that
+// installation.
+func GoCommand(ctx context.Context, root string, args ...string) *exec.Cmd {
 	newPath := filepath.Join(root, "bin")
 	if p := os.Getenv("PATH"); p != "" {
 		newPath += string(filepath.ListSeparator) + p
 	}
+	cmd := exec.CommandContext(ctx, filepath.Join(root, "bin", "go"+exe()), args...)
 	cmd.Env = dedupEnv(caseInsensitiveEnv, append(os.Environ(), "GOROOT="+root, "PATH="+newPath))
+	return cmd
+}
+
+// runGo runs the go command installed in root with the arguments of
+// this process, and exits with its status. If useExec reports true, the
+// go command replaces this process, and runGo does not return unless
+// exec fails. Otherwise, or if exec fails, the go command runs as a
+// child process, and this process ignores the signals meant for it.
+func runGo(root string) {
+	cmd := GoCommand(context.Background(), root, os.Args[1:]...)
+
+	if useExec() {
+		// execCmd only returns if exec fails; the go command then
+		// runs as a child process below.
+		err := execCmd(cmd)
+		log.Printf("exec %s: %v; falling back to running it as a child process", cmd.Path, err)
+	}
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
 
 	handleSignals()
 
@@ -77,6 +126,22 @@ func runGo(root string) {
 	os.Exit(0)
 }
 
+// execCmd replaces the current process with cmd, with the arguments
+// and environment it would run with as a child process.
+func execCmd(cmd *exec.Cmd) error {
+	return execGo(cmd.Path, cmd.Args[1:], cmd.Env)
+}
+
+// useExec reports whether runGo should replace the current process with
+// the go command instead of running it as a child. It is enabled by
+// the GODL_EXEC setting and is only honored where exec is available.
+func useExec() bool {
+	if !canExec {
+		return false
+	}
+	return new(Installer).getenvBool("GODL_EXEC")
+}
+
 func fmtSize(size int64) string {
 	const (
 		byte_unit = 1 << (10 * iota)
@@ -99,317 +164,513 @@ func fmtSize(size int64) string {
 	return fmt.Sprintf("%s %s", formatted, unit)
 }
 
-// install installs a version of Go to the named target directory, creating the
-// directory as needed.
-func install(targetDir, version string) error {
-	if _, err := os.Stat(filepath.Join(targetDir, unpackedOkay)); err == nil {
-		log.Printf("%s: already downloaded in %v", version, targetDir)
-		return nil
+// install installs a version of Go to the named target directory using
+// in, stopping cleanly if interrupted.
+func install(in *Installer, targetDir, version string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return in.Install(ctx, targetDir, version)
+}
+
+// newInstaller returns an Installer configured by the GODL_TIMEOUT,
+// GODL_IDLE_TIMEOUT, GODL_CHUNKS, GODL_RATE_LIMIT, GODL_UNPACK_WORKERS,
+// GODL_PROFILE, GODL_INCLUDE, GODL_EXCLUDE, GODL_DEDUPE,
+// GODL_SMOKE_TEST and GODL_READONLY settings. The idle timeout defaults
+// to one minute.
+func newInstaller() *Installer {
+	in := &Installer{
+		Profile:   profileSetting(),
+		Dedupe:    getenv("GODL_DEDUPE"),
+		SmokeTest: smokeTestSetting(),
+	}
+	in.Timeout = in.durationSetting("GODL_TIMEOUT", 0)
+	in.IdleTimeout = in.durationSetting("GODL_IDLE_TIMEOUT", time.Minute)
+	in.Chunks = in.intSetting("GODL_CHUNKS", 1)
+	in.RateLimit = in.rateSetting()
+	in.UnpackWorkers = in.intSetting("GODL_UNPACK_WORKERS", 0)
+	in.ReadOnly = in.readOnlySetting()
+	return in
+}
+
+// DefaultBaseURL is the URL of the directory holding the official
+// binary releases.
+const DefaultBaseURL = "https://dl.google.com/go/"
+
+// An Installer downloads and installs binary releases of Go.
+// The zero value installs from DefaultBaseURL, reporting progress and
+// log messages to standard error.
+type Installer struct {
+	// BaseURL is the URL of the source of release archives, in any
+	// form accepted by the GODL_SOURCES setting, or several such URLs
+	// separated by "|" to try in turn. If empty, the sources are chosen
+	// by GODL_SOURCES, and default to DefaultBaseURL.
+	BaseURL string
+
+	// ChecksumURL is the URL of the trusted source of archive
+	// checksums. If empty, it is set by the GODL_CHECKSUM_SOURCE
+	// setting, and otherwise defaults to DefaultBaseURL. If it is
+	// ChecksumFromMirror, each archive is checked against the
+	// checksum of the source that serves it, as private builds that
+	// the official site lacks must be.
+	ChecksumURL string
+
+	// Fastest makes Install try the sources in order of how quickly
+	// they answer rather than in the order given, as does setting
+	// GODL_MIRROR_SELECT to "fastest".
+	Fastest bool
+
+	// Client is used for all HTTP requests. If nil, clients configured
+	// by the GODL_CA_BUNDLE, GODL_CLIENT_CERT, GODL_CLIENT_KEY,
+	// GODL_PROXY, GODL_AUTH and GODL_NETRC settings are used, with
+	// compression disabled for archives.
+	Client *http.Client
+
+	// Progress receives the progress of downloading, verifying and
+	// unpacking. If nil, progress is written to standard error as
+	// configured by the GODL_PROGRESS setting.
+	Progress Progress
+
+	// Logf logs informational messages. If nil, log.Printf is used.
+	Logf func(format string, args ...any)
+
+	// Timeout limits the duration of the whole install. Zero means no
+	// limit.
+	Timeout time.Duration
+
+	// IdleTimeout limits how long any request waits for more data from
+	// the server. Zero means no limit.
+	IdleTimeout time.Duration
+
+	// RateLimit limits archive downloads to this many bytes per
+	// second, across all the ranges of a chunked download. Zero means
+	// no limit.
+	RateLimit int64
+
+	// Chunks is the number of ranges to split an archive download
+	// into, fetched concurrently, for links where one connection is
+	// slower than the available bandwidth. Each range is at least
+	// 1 MiB. Zero or one means a single stream, as does a server that
+	// does not support range requests.
+	Chunks int
+
+	// UnpackWorkers is the number of files to write at a time when
+	// unpacking an archive. Zero means twice the number of CPUs, up to
+	// 16; one means writing files one by one.
+	UnpackWorkers int
+
+	// Profile selects the files of the distribution to install. The
+	// zero value installs every file.
+	Profile Profile
+
+	// Dedupe is how to share files with other versions installed in
+	// the same SDK root, through its content-addressed store: "auto"
+	// for copy-on-write clones where the file system supports them and
+	// hard links otherwise, "reflink" or "hardlink" for only one of the
+	// two, or "off" or empty not to.
+	Dedupe string
+
+	// SHA256 is the hex SHA-256 checksum that the archive must have,
+	// such as one pinned by a lockfile. If empty, the checksum is
+	// fetched from the checksum source. As it pins the archive of the
+	// host platform, no fallback platform's archive is tried.
+	SHA256 string
+
+	// SmokeTest selects the checks that Install runs on the unpacked
+	// toolchain before marking it installed: "version" to run its go
+	// command and check the version and platform it reports, "build"
+	// to also build a program, or "off" or empty for none. Installs
+	// that leave out files always build a program.
+	SmokeTest string
+
+	// ReadOnly makes Install remove write permission from the
+	// installed files once installed, so that they are not edited by
+	// accident.
+	ReadOnly bool
+}
+
+func (in *Installer) logf(format string, args ...any) {
+	if in.Logf != nil {
+		in.Logf(format, args...)
+		return
 	}
+	log.Printf(format, args...)
+}
 
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		return err
+func (in *Installer) progress() Progress {
+	if in.Progress != nil {
+		return in.Progress
+	}
+	return progressSetting()
+}
+
+func (in *Installer) client() *http.Client {
+	if in.Client != nil {
+		return in.Client
 	}
-	goURL := versionArchiveURL(version)
-	res, err := http.Head(goURL)
+	return httpClient(netConfigSetting(), false)
+}
+
+// Install installs a version of Go to the named target directory,
+// creating the directory as needed. It does nothing if the version is
+// already installed there, unless it was installed with a profile that
+// left out files and in.Profile installs every file, in which case the
+// missing files are added. The unpacked toolchain is checked as
+// in.SmokeTest selects, and installs that leave out files are checked by
+// building a program with the installed go command. If adding files
+// fails, the files added are removed again, leaving the version
+// installed as it was.
+//
+// If ctx is canceled or a timeout expires, Install stops, keeping any
+// partially downloaded archive so that the next attempt resumes it, and
+// removing any partially unpacked files.
+func (in *Installer) Install(ctx context.Context, targetDir, version string) error {
+	keep, err := in.Profile.filter()
 	if err != nil {
 		return err
 	}
-	if res.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("no binary release of %v for %v/%v at %v", version, getOS(), runtime.GOARCH, goURL)
+	if err := checkDedupeMode(in.Dedupe); err != nil {
+		return err
 	}
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("server returned %v checking size of %v", http.StatusText(res.StatusCode), goURL)
+	if err := checkSmokeTest(in.SmokeTest); err != nil {
+		return err
 	}
-	base := path.Base(goURL)
-	archiveFile := filepath.Join(targetDir, base)
-	if fi, err := os.Stat(archiveFile); err != nil || fi.Size() != res.ContentLength {
-		if err != nil && !os.IsNotExist(err) {
-			// Something weird. Don't try to download.
-			return err
-		}
-		if err := copyFromURL(archiveFile, goURL); err != nil {
-			return fmt.Errorf("error downloading %v: %v", goURL, err)
+	upgrade, wasReadOnly := false, false
+	var installed *platform // of the archive installed, if recorded
+	if IsInstalled(targetDir) {
+		var old Profile
+		if r, err := ReadReceipt(targetDir); err == nil {
+			if r.Profile != nil {
+				old = *r.Profile
+			}
+			wasReadOnly = r.ReadOnly
+			if p, err := parsePlatforms(r.Platform); err == nil && len(p) == 1 {
+				installed = &p[0]
+			}
 		}
-		fi, err = os.Stat(archiveFile)
+		add, err := in.Profile.upgradeFilter(old)
 		if err != nil {
-			return err
+			return fmt.Errorf("%s: %w", version, err)
 		}
-		if fi.Size() != res.ContentLength {
-			return fmt.Errorf("downloaded file %s size %v doesn't match server size %v", archiveFile, fi.Size(), res.ContentLength)
+		if add == nil {
+			in.logf("%s: already downloaded in %v", version, targetDir)
+			return nil
 		}
+		in.logf("%s: adding the files that profile %v left out", version, old)
+		keep, upgrade = add, true
 	}
-	wantSHA, err := slurpURLToString(goURL + ".sha256")
-	if err != nil {
-		return err
-	}
-	if err := verifySHA256(archiveFile, strings.TrimSpace(wantSHA)); err != nil {
-		return fmt.Errorf("error verifying SHA256 of %v: %v", archiveFile, err)
+	if in.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, in.Timeout)
+		defer cancel()
 	}
-	log.Printf("Unpacking %v ...", archiveFile)
-	if err := unpackArchive(targetDir, archiveFile); err != nil {
-		return fmt.Errorf("extracting archive %v: %v", archiveFile, err)
-	}
-	if err := os.WriteFile(filepath.Join(targetDir, unpackedOkay), nil, 0644); err != nil {
-		return err
-	}
-	log.Printf("Success. You may now run '%v'", version)
-	return nil
-}
 
-// unpackArchive unpacks the provided archive zip or tar.gz file to targetDir,
-// removing the "go/" prefix from file entries.
-func unpackArchive(targetDir, archiveFile string) error {
-	switch {
-	case strings.HasSuffix(archiveFile, ".zip"):
-		return unpackZip(targetDir, archiveFile)
-	case strings.HasSuffix(archiveFile, ".tar.gz"):
-		return unpackTarGz(targetDir, archiveFile)
-	default:
-		return errors.New("unsupported archive file")
-	}
-}
-
-// unpackTarGz is the tar.gz implementation of unpackArchive.
-func unpackTarGz(targetDir, archiveFile string) error {
-	r, err := os.Open(archiveFile)
-	if err != nil {
+	if err := mkdirVersion(targetDir, isSharedRoot(filepath.Dir(targetDir))); err != nil {
 		return err
 	}
-	defer r.Close()
-	madeDir := map[string]bool{}
-	zr, err := gzip.NewReader(r)
+	shared := isGroupShared(targetDir)
+	mirrors, err := in.sources(version)
 	if err != nil {
 		return err
 	}
-	tr := tar.NewReader(zr)
-	for {
-		f, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
+	var sums source
+	if in.SHA256 == "" {
+		if sums, err = in.checksumSource(); err != nil {
 			return err
 		}
-		if !validRelPath(f.Name) {
-			return fmt.Errorf("tar file contained invalid name %q", f.Name)
-		}
-		rel := filepath.FromSlash(strings.TrimPrefix(f.Name, "go/"))
-		abs := filepath.Join(targetDir, rel)
-
-		fi := f.FileInfo()
-		mode := fi.Mode()
-		switch {
-		case mode.IsRegular():
-			// Make the directory. This is redundant because it should
-			// already be made by a directory entry in the tar
-			// beforehand. Thus, don't check for errors; the next
-			// write will fail with the same error.
-			dir := filepath.Dir(abs)
-			if !madeDir[dir] {
-				if err := os.MkdirAll(filepath.Dir(abs), 0755); err != nil {
-					return err
-				}
-				madeDir[dir] = true
-			}
-			wf, err := os.OpenFile(abs, os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode.Perm())
-			if err != nil {
-				return err
-			}
-			n, err := io.Copy(wf, tr)
-			if closeErr := wf.Close(); closeErr != nil && err == nil {
-				err = closeErr
-			}
-			if err != nil {
-				return fmt.Errorf("error writing to %s: %v", abs, err)
-			}
-			if n != f.Size {
-				return fmt.Errorf("only wrote %d bytes to %s; expected %d", n, abs, f.Size)
-			}
-			if !f.ModTime.IsZero() {
-				if err := os.Chtimes(abs, f.ModTime, f.ModTime); err != nil {
-					// benign error. Gerrit doesn't even set the
-					// modtime in these, and we don't end up relying
-					// on it anywhere (the gomote push command relies
-					// on digests only), so this is a little pointless
-					// for now.
-					log.Printf("error changing modtime: %v", err)
-				}
-			}
-		case mode.IsDir():
-			if err := os.MkdirAll(abs, 0755); err != nil {
-				return err
-			}
-			madeDir[abs] = true
-		default:
-			return fmt.Errorf("tar file entry %s contained unsupported file type %v", f.Name, mode)
-		}
-	}
-	return nil
-}
-
-// unpackZip is the zip implementation of unpackArchive.
-func unpackZip(targetDir, archiveFile string) error {
-	zr, err := zip.OpenReader(archiveFile)
-	if err != nil {
-		return err
 	}
-	defer zr.Close()
-
-	for _, f := range zr.File {
-		name := strings.TrimPrefix(f.Name, "go/")
-
-		outpath := filepath.Join(targetDir, name)
-		if f.FileInfo().IsDir() {
-			if err := os.MkdirAll(outpath, 0755); err != nil {
-				return err
-			}
-			continue
+	platforms := installPlatforms()
+	switch {
+	case upgrade && installed != nil:
+		// Add files from the same archive.
+		platforms = []platform{*installed}
+	case in.SHA256 != "":
+		// Another platform's archive cannot match the pinned checksum.
+		platforms = platforms[:1]
+	}
+	progress := in.progress()
+	var (
+		plat        platform
+		base        string
+		archiveFile string
+		src         source
+		wantSHA     string
+	)
+	for i, p := range platforms {
+		plat, base = p, p.archive(version)
+		archiveFile = filepath.Join(targetDir, base)
+		src, wantSHA, err = in.fetchArchive(ctx, version, archiveFile, in.orderMirrors(ctx, mirrors, base), sums, progress)
+		if err == nil {
+			break
 		}
-
-		rc, err := f.Open()
-		if err != nil {
+		if !errors.Is(err, ErrNotFound) || i == len(platforms)-1 {
 			return err
 		}
-
-		// File
-		if err := os.MkdirAll(filepath.Dir(outpath), 0755); err != nil {
+		in.logf("%s: no binary release for %s; trying the one for %s, which runs there too", version, p, platforms[i+1])
+	}
+	var had map[string]bool // files of the version being upgraded
+	if upgrade {
+		if had, err = listUnpacked(targetDir); err != nil {
 			return err
 		}
-		out, err := os.OpenFile(outpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
+		// Until the added files are all in place, the version is
+		// not installed.
+		if err := os.Remove(filepath.Join(targetDir, unpackedOkay)); err != nil {
 			return err
 		}
-		_, err = io.Copy(out, rc)
-		rc.Close()
-		if err != nil {
-			out.Close()
+		if err := makeWritable(targetDir); err != nil {
 			return err
 		}
-		if err := out.Close(); err != nil {
+	}
+	// discard removes the files unpacked so far, leaving a version
+	// being upgraded installed as it was.
+	discard := func() error {
+		if !upgrade {
+			return removeUnpacked(targetDir, base)
+		}
+		if err := removeAdded(targetDir, had); err != nil {
 			return err
 		}
+		if wasReadOnly {
+			if err := makeReadOnly(targetDir); err != nil {
+				return err
+			}
+		}
+		return os.WriteFile(filepath.Join(targetDir, unpackedOkay), nil, groupPerm(0644, shared))
+	}
+	in.logf("Unpacking %v ...", archiveFile)
+	if err := unpackArchive(ctx, targetDir, archiveFile, unpackOptions{workers: in.UnpackWorkers, keep: keep, logf: in.logf}, progress); err != nil {
+		if err := discard(); err != nil {
+			in.logf("%s: removing partially unpacked files: %v", version, err)
+		}
+		return fmt.Errorf("extracting archive %v: %w", archiveFile, err)
 	}
+	var profile *Profile
+	if !in.Profile.full() {
+		profile = &in.Profile
+	}
+	if err := in.runSmokeTests(ctx, targetDir, version, plat); err != nil {
+		// Keep the archive, so that it can be inspected or the
+		// install retried without downloading it again.
+		if err := discard(); err != nil {
+			in.logf("%s: removing unpacked files: %v", version, err)
+		}
+		return err
+	}
+	if dedupeEnabled(in.Dedupe) {
+		// A version whose files are not all shared still works.
+		if stats, err := dedupe(targetDir, in.Dedupe); err != nil {
+			in.logf("%s: sharing files with other versions: %v", version, err)
+		} else if stats.Shared > 0 {
+			in.logf("Shared %d of %d files with other versions, saving %s", stats.Shared, stats.Files, fmtSize(stats.Saved))
+		}
+	}
+	readOnly := false
+	if in.ReadOnly {
+		// A version that is still writable still works.
+		if err := makeReadOnly(targetDir); err != nil {
+			in.logf("%s: making files read-only: %v", version, err)
+		} else {
+			readOnly = true
+		}
+	}
+	if err := writeReceipt(&Receipt{
+		Version:     version,
+		Root:        targetDir,
+		URL:         src.url(base),
+		Mirror:      src.url(""),
+		SHA256:      wantSHA,
+		Platform:    plat.String(),
+		Profile:     profile,
+		ReadOnly:    readOnly,
+		InstalledAt: time.Now().UTC(),
+	}); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(targetDir, unpackedOkay), nil, groupPerm(0644, shared)); err != nil {
+		return err
+	}
+	in.logf("Success. You may now run '%v'", version)
 	return nil
 }
 
 // verifySHA256 reports whether the named file has contents with
 // SHA-256 of the given wantHex value.
-func verifySHA256(file, wantHex string) error {
+func verifySHA256(file, wantHex string, p Progress) error {
 	f, err := os.Open(file)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
 	hash := sha256.New()
-	if _, err := io.Copy(hash, f); err != nil {
+	pw := newProgressWriter(hash, p, PhaseVerify, 0, fi.Size())
+	if _, err := io.Copy(pw, f); err != nil {
 		return err
 	}
-	if fmt.Sprintf("%x", hash.Sum(nil)) != wantHex {
-		return fmt.Errorf("%s corrupt? does not have expected SHA-256 of %v", file, wantHex)
+	pw.done()
+	if got := fmt.Sprintf("%x", hash.Sum(nil)); got != wantHex {
+		return &ChecksumError{File: file, Want: wantHex, Got: got}
 	}
 	return nil
 }
 
 // slurpURLToString downloads the given URL and returns it as a string.
-func slurpURLToString(url_ string) (string, error) {
-	res, err := http.Get(url_)
+func (in *Installer) slurpURLToString(ctx context.Context, url_ string) (string, error) {
+	res, err := in.get(ctx, in.client(), "GET", url_, nil)
 	if err != nil {
 		return "", err
 	}
 	defer res.Body.Close()
 	if res.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("%s: %v", url_, res.Status)
+		return "", &NetworkError{URL: url_, StatusCode: res.StatusCode}
 	}
 	slurp, err := io.ReadAll(res.Body)
 	if err != nil {
-		return "", fmt.Errorf("reading %s: %v", url_, err)
+		return "", fmt.Errorf("reading %s: %w", url_, err)
 	}
 	return string(slurp), nil
 }
 
-// copyFromURL downloads srcURL to dstFile.
-func copyFromURL(dstFile, srcURL string) (err error) {
-	f, err := os.Create(dstFile)
+// copyFromURL downloads srcURL, of the given size or -1 if unknown, to
+// dstFile, as copyFrom does.
+func (in *Installer) copyFromURL(ctx context.Context, dstFile, srcURL string, size int64) error {
+	return in.copyFrom(ctx, dstFile, redactURL(srcURL), size, func(ctx context.Context, offset, length int64) (io.ReadCloser, int64, int64, error) {
+		return in.fetchRange(ctx, srcURL, offset, length)
+	})
+}
+
+// An openFunc opens a file being downloaded for reading length bytes
+// from offset, as a source's open method does.
+type openFunc func(ctx context.Context, offset, length int64) (r io.ReadCloser, start, size int64, err error)
+
+// copyFrom downloads the file at srcURL, of the given size or -1 if
+// unknown, to dstFile, reading it with open. The download goes to
+// dstFile+".partial" first, which is kept if the download fails so that
+// the next call resumes it where the source supports that. If in.Chunks
+// is more than one and the size is known, the download is split into
+// that many ranges fetched concurrently.
+func (in *Installer) copyFrom(ctx context.Context, dstFile, srcURL string, size int64, open openFunc) error {
+	return in.copyPartial(ctx, dstFile, srcURL, size, in.limitOpen(open))
+}
+
+// copyPartial implements copyFrom.
+func (in *Installer) copyPartial(ctx context.Context, dstFile, srcURL string, size int64, open openFunc) (err error) {
+	partial := dstFile + ".partial"
+	f, err := os.OpenFile(partial, os.O_WRONLY|os.O_CREATE, 0666)
 	if err != nil {
 		return err
 	}
 	defer func() {
 		if err != nil {
 			f.Close()
-			os.Remove(dstFile)
 		}
 	}()
-	c := &http.Client{
-		Transport: &userAgentTransport{&http.Transport{
-			// It's already compressed. Prefer accurate ContentLength.
-			// (Not that GCS would try to compress it, though)
-			DisableCompression: true,
-			DisableKeepAlives:  true,
-			Proxy:              http.ProxyFromEnvironment,
-		}},
-	}
-	res, err := c.Get(srcURL)
+	offset, err := f.Seek(0, io.SeekEnd)
 	if err != nil {
 		return err
 	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return errors.New(res.Status)
+	if size >= 0 && offset > size {
+		// The partial file is no prefix of the archive. Start over.
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		if offset, err = f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	if n := in.chunks(size - offset); size >= 0 && n > 1 {
+		if offset > 0 {
+			in.logf("Resuming download at %s", fmtSize(offset))
+		}
+		err := in.copyChunked(ctx, f, srcURL, offset, size, n, open)
+		if err == nil {
+			if err := f.Close(); err != nil {
+				return err
+			}
+			return os.Rename(partial, dstFile)
+		}
+		if err != errNoRanges {
+			return err
+		}
+		in.logf("%s does not support range requests; downloading in one stream", srcURL)
+	}
+	r, start, rsize, err := open(ctx, offset, -1)
+	if offset > 0 && err == errBadRange {
+		// The partial file is no prefix of the archive. Start over.
+		f.Close()
+		if err := os.Remove(partial); err != nil {
+			return err
+		}
+		return in.copyPartial(ctx, dstFile, srcURL, size, open)
 	}
-	pw := &progressWriter{w: f, total: res.ContentLength, output: os.Stderr}
-	n, err := io.Copy(pw, res.Body)
 	if err != nil {
 		return err
 	}
-	if res.ContentLength != -1 && res.ContentLength != n {
-		return fmt.Errorf("copied %v bytes; expected %v", n, res.ContentLength)
+	defer r.Close()
+	size = rsize
+	if start == offset && offset > 0 {
+		in.logf("Resuming download at %s", fmtSize(offset))
+	} else if start != offset {
+		// The source ignored the offset.
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
 	}
-	pw.update() // 100%
-	return f.Close()
-}
-
-type progressWriter struct {
-	w         io.Writer
-	n         int64
-	total     int64
-	last      time.Time
-	formatted bool
-	output    io.Writer
-}
-
-func (p *progressWriter) update() {
-	end := " ..."
-	if p.n == p.total {
-		end = ""
+	total := size
+	if total != -1 {
+		total += start
 	}
-	if p.formatted {
-		fmt.Fprintf(p.output, "Downloaded %5.1f%% (%s / %s)%s\n",
-			(100.0*float64(p.n))/float64(p.total),
-			fmtSize(p.n), fmtSize(p.total), end)
-	} else {
-		fmt.Fprintf(p.output, "Downloaded %5.1f%% (%*d / %d bytes)%s\n",
-			(100.0*float64(p.n))/float64(p.total),
-			ndigits(p.total), p.n, p.total, end)
+	pw := newProgressWriter(f, in.downloadProgress(), PhaseDownload, start, total)
+	n, err := io.Copy(pw, r)
+	if err != nil {
+		return err
 	}
-}
-
-func ndigits(i int64) int {
-	var n int
-	for ; i != 0; i /= 10 {
-		n++
+	if size != -1 && size != n {
+		return &NetworkError{URL: srcURL, Err: fmt.Errorf("copied %v bytes; expected %v", n, size)}
+	}
+	pw.done() // 100%
+	if err := f.Close(); err != nil {
+		return err
 	}
-	return n
+	return os.Rename(partial, dstFile)
 }
 
-func (p *progressWriter) Write(buf []byte) (n int, err error) {
-	n, err = p.w.Write(buf)
-	p.n += int64(n)
-	if now := time.Now(); now.Unix() != p.last.Unix() {
-		p.update()
-		p.last = now
+// fetchRange fetches length bytes of srcURL from offset on, as a
+// source's open method.
+func (in *Installer) fetchRange(ctx context.Context, srcURL string, offset, length int64) (io.ReadCloser, int64, int64, error) {
+	c := in.Client
+	if c == nil {
+		c = httpClient(netConfigSetting(), true)
+	}
+	header := http.Header{}
+	ranged := offset > 0 || length >= 0
+	if length >= 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	} else if offset > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	res, err := in.get(ctx, c, "GET", srcURL, header)
+	if err != nil {
+		return nil, 0, 0, err
 	}
-	return
+	switch {
+	case ranged && res.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		res.Body.Close()
+		return nil, 0, 0, errBadRange
+	case ranged && res.StatusCode == http.StatusPartialContent:
+		return res.Body, offset, res.ContentLength, nil
+	case res.StatusCode == http.StatusOK:
+		// The server ignored the range, if any.
+		return res.Body, 0, res.ContentLength, nil
+	}
+	res.Body.Close()
+	return nil, 0, 0, &NetworkError{URL: srcURL, StatusCode: res.StatusCode}
 }
 
 // getOS returns runtime.GOOS. It exists as a function just for lazy
@@ -418,19 +679,10 @@ func getOS() string {
 	return runtime.GOOS
 }
 
-// versionArchiveURL returns the zip or tar.gz URL of the given Go version.
-func versionArchiveURL(version string) string {
-	goos := getOS()
-
-	ext := ".tar.gz"
-	if goos == "windows" {
-		ext = ".zip"
-	}
-	arch := runtime.GOARCH
-	if goos == "linux" && runtime.GOARCH == "arm" {
-		arch = "armv6l"
-	}
-	return "https://dl.google.com/go/" + version + "." + goos + "-" + arch + ext
+// archiveName returns the file name of the zip or tar.gz archive of the
+// given Go version for the host.
+func archiveName(version string) string {
+	return hostPlatform().archive(version)
 }
 
 const caseInsensitiveEnv = runtime.GOOS == "windows"
@@ -446,18 +698,6 @@ func exe() string {
 	return ""
 }
 
-func goroot(version string) (string, error) {
-	if dir := os.Getenv("GOPATH"); dir != "" {
-		return filepath.Join(dir, "sdk", version), nil
-	}
-
-	home, err := homedir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %v", err)
-	}
-	return filepath.Join(home, "sdk", version), nil
-}
-
 func homedir() (string, error) {
 	// This could be replaced with os.UserHomeDir, but it was introduced too
 	// recently, and we want this to work with go as packaged by Linux
@@ -482,13 +722,8 @@ func homedir() (string, error) {
 	}
 }
 
-func validRelPath(p string) bool {
-	if p == "" || strings.Contains(p, `\`) || strings.HasPrefix(p, "/") || strings.Contains(p, "../") {
-		return false
-	}
-	return true
-}
-
+// A userAgentTransport sets the User-Agent header of the requests of
+// the installer's own clients.
 type userAgentTransport struct {
 	rt http.RoundTripper
 }
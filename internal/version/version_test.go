@@ -6,8 +6,13 @@ package version
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -42,8 +47,8 @@ func TestFormatted(t *testing.T) {
 	var buff = new(bytes.Buffer)
 	var units = []string{"B", "KB", "MB"}
 	for i := 1; i < 4; i++ {
-		pw := &progressWriter{w: nil, total: total, formatted: true, output: buff}
-		pw.update()
+		p := &linesProgress{formatted: true, output: buff}
+		p.update(ProgressEvent{Phase: PhaseDownload, Total: total})
 		total *= 1024
 		expected := fmt.Sprintf("%d %s", 1, units[i-1])
 		if !strings.Contains(buff.String(), expected) {
@@ -56,8 +61,8 @@ func TestUnFormatted(t *testing.T) {
 	var total int64 = 1
 	var buff = new(bytes.Buffer)
 	for i := 1; i < 4; i++ {
-		pw := &progressWriter{w: nil, total: total, formatted: false, output: buff}
-		pw.update()
+		p := &linesProgress{formatted: false, output: buff}
+		p.update(ProgressEvent{Phase: PhaseDownload, Total: total})
 		expected := fmt.Sprintf("%d bytes", total)
 		if !strings.Contains(buff.String(), expected) {
 			t.Errorf("expected: %s received: %s", expected, buff.String())
@@ -65,3 +70,85 @@ func TestUnFormatted(t *testing.T) {
 		total *= 1024
 	}
 }
+
+func TestAutoDownload(t *testing.T) {
+	if os.Getenv("GODL_TEST_RUN") != "" {
+		// The child process: run the wrapper command go1.99.1.
+		os.Args = []string{"go1.99.1", "version"}
+		Run("go1.99.1")
+		return
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake go command is a shell script")
+	}
+	tests := []struct {
+		name   string
+		env    *string // GODL_AUTODOWNLOAD in the environment, if set
+		config string  // GODL_AUTODOWNLOAD in the config file, if set
+		want   int     // exit code
+	}{
+		{name: "unset", want: exitNotInstalled},
+		{name: "env on", env: ptr("1"), want: 0},
+		{name: "env off", env: ptr("false"), want: exitNotInstalled},
+		{name: "config on", config: "true", want: 0},
+		{name: "config off", config: "false", want: exitNotInstalled},
+		{name: "env off over config", env: ptr("false"), config: "true", want: exitNotInstalled},
+		{name: "empty env over config", env: ptr(""), config: "true", want: exitNotInstalled},
+	}
+	sources := profileArchive(t, "go1.99.1", `echo fake go "$@"`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			config := filepath.Join(dir, "config")
+			if tt.config != "" {
+				if err := os.WriteFile(config, []byte("GODL_AUTODOWNLOAD="+tt.config+"\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			cmd := exec.Command(os.Args[0], "-test.run=^TestAutoDownload$")
+			for _, kv := range os.Environ() {
+				if !strings.HasPrefix(kv, "GODL_") {
+					cmd.Env = append(cmd.Env, kv)
+				}
+			}
+			cmd.Env = append(cmd.Env,
+				"GODL_TEST_RUN=1",
+				"GODL_CONFIG="+config,
+				"GODL_SDKROOT="+filepath.Join(dir, "sdk"),
+				"GODL_SOURCES=*="+sources,
+				"GODL_CHECKSUM_SOURCE=mirror",
+				"GODL_SMOKE_TEST=off",
+				"GODL_READONLY=false",
+				"GODL_PROGRESS=quiet",
+			)
+			if tt.env != nil {
+				cmd.Env = append(cmd.Env, "GODL_AUTODOWNLOAD="+*tt.env)
+			}
+			var stdout, stderr strings.Builder
+			cmd.Stdout, cmd.Stderr = &stdout, &stderr
+			err := cmd.Run()
+			code := 0
+			var eerr *exec.ExitError
+			if errors.As(err, &eerr) {
+				code = eerr.ExitCode()
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if code != tt.want {
+				t.Fatalf("exit code = %d; want %d\nstdout:\n%s\nstderr:\n%s", code, tt.want, &stdout, &stderr)
+			}
+			// Only the go command writes to stdout.
+			installed := IsInstalled(filepath.Join(dir, "sdk", "go1.99.1"))
+			switch {
+			case tt.want == 0 && (!installed || stdout.String() != "fake go version\n"):
+				t.Errorf("go1.99.1 version with auto-download did not install and run go1.99.1; stdout:\n%s", &stdout)
+			case tt.want == 0 && !strings.Contains(stderr.String(), "go1.99.1: not downloaded; installing to "):
+				t.Errorf("go1.99.1 version with auto-download did not report installing on stderr:\n%s", &stderr)
+			case tt.want != 0 && (installed || stdout.Len() != 0 || !strings.Contains(stderr.String(), "go1.99.1: not downloaded. Run 'go1.99.1 download'")):
+				t.Errorf("go1.99.1 version without auto-download did not report it not downloaded on stderr; stdout:\n%s\nstderr:\n%s", &stdout, &stderr)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
@@ -0,0 +1,173 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A goVersion is a parsed Go version such as go1.21rc2 or go1.22.5.
+type goVersion struct {
+	major, minor, patch int
+	kind                string // "" for a language version such as go1.21, "beta", "rc" or "release"
+	pre                 int    // beta or rc number
+}
+
+// parseVersion parses a Go version, with or without the "go" prefix.
+func parseVersion(v string) (goVersion, bool) {
+	var gv goVersion
+	s := strings.TrimPrefix(v, "go")
+	num := func() (int, bool) {
+		i := 0
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			i++
+		}
+		n, err := strconv.Atoi(s[:i])
+		s = s[i:]
+		return n, err == nil
+	}
+	var ok bool
+	if gv.major, ok = num(); !ok {
+		return goVersion{}, false
+	}
+	if s == "" {
+		return gv, true
+	}
+	if s[0] != '.' {
+		return goVersion{}, false
+	}
+	s = s[1:]
+	if gv.minor, ok = num(); !ok {
+		return goVersion{}, false
+	}
+	switch {
+	case s == "":
+		// A language version, such as go1.21. Before Go 1.21, this
+		// also named the first release.
+	case strings.HasPrefix(s, "."):
+		s = s[1:]
+		if gv.patch, ok = num(); !ok || s != "" {
+			return goVersion{}, false
+		}
+		gv.kind = "release"
+	case strings.HasPrefix(s, "beta"), strings.HasPrefix(s, "rc"):
+		gv.kind = strings.TrimRight(s, "0123456789")
+		s = s[len(gv.kind):]
+		if gv.pre, ok = num(); !ok || s != "" {
+			return goVersion{}, false
+		}
+	default:
+		return goVersion{}, false
+	}
+	return gv, true
+}
+
+var kindRank = map[string]int{"": 0, "beta": 1, "rc": 2, "release": 3}
+
+// cmpVersion compares two Go versions as the go command does, so that
+// go1.21 < go1.21rc1 < go1.21.0 < go1.21.1. Invalid versions sort
+// before valid ones and compare as strings among themselves.
+func cmpVersion(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return strings.Compare(a, b)
+	case !okA:
+		return -1
+	case !okB:
+		return +1
+	}
+	for _, d := range []int{
+		va.major - vb.major,
+		va.minor - vb.minor,
+		kindRank[va.kind] - kindRank[vb.kind],
+		va.pre - vb.pre,
+		va.patch - vb.patch,
+	} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return +1
+		}
+	}
+	return 0
+}
+
+// CompareVersions compares two Go versions, with or without the "go"
+// prefix, as the go command does, returning -1, 0 or +1. Invalid
+// versions sort before valid ones.
+func CompareVersions(a, b string) int {
+	return cmpVersion(a, b)
+}
+
+// A versionFilter selects Go versions by a comma-separated list of
+// constraints, all of which must hold. Each constraint is an operator
+// (>=, >, <=, < or =) followed by a version, or a bare version. A bare
+// language version such as 1.22 matches every version of that language
+// version, such as go1.22rc1 and go1.22.5; any other bare version
+// matches only itself.
+type versionFilter []versionConstraint
+
+type versionConstraint struct {
+	op      string
+	version string
+}
+
+func parseVersionFilter(s string) (versionFilter, error) {
+	var f versionFilter
+	for _, c := range strings.Split(s, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		op := ""
+		for _, o := range []string{">=", "<=", ">", "<", "="} {
+			if strings.HasPrefix(c, o) {
+				op = o
+				break
+			}
+		}
+		v := "go" + strings.TrimPrefix(strings.TrimSpace(c[len(op):]), "go")
+		if _, ok := parseVersion(v); !ok {
+			return nil, fmt.Errorf("invalid version constraint %q", c)
+		}
+		f = append(f, versionConstraint{op, v})
+	}
+	return f, nil
+}
+
+// match reports whether version satisfies every constraint of f.
+func (f versionFilter) match(version string) bool {
+	for _, c := range f {
+		cmp := cmpVersion(version, c.version)
+		var ok bool
+		switch c.op {
+		case ">=":
+			ok = cmp >= 0
+		case ">":
+			ok = cmp > 0
+		case "<=":
+			ok = cmp <= 0
+		case "<":
+			ok = cmp < 0
+		case "=":
+			ok = cmp == 0
+		case "":
+			ok = cmp == 0
+			if cv, _ := parseVersion(c.version); cv.kind == "" {
+				v, valid := parseVersion(version)
+				ok = valid && v.major == cv.major && v.minor == cv.minor
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package version
+
+import "testing"
+
+func TestCmpVersion(t *testing.T) {
+	// In increasing order.
+	versions := []string{"go1.9", "go1.9.1", "go1.20", "go1.21", "go1.21beta1", "go1.21rc1", "go1.21rc2", "go1.21.0", "go1.21.1", "go1.21.10", "go1.22.0"}
+	for i, a := range versions {
+		for j, b := range versions {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = +1
+			}
+			if got := cmpVersion(a, b); got != want {
+				t.Errorf("cmpVersion(%q, %q) = %d; want %d", a, b, got, want)
+			}
+		}
+	}
+}
+
+func TestVersionFilter(t *testing.T) {
+	tests := []struct {
+		filter  string
+		version string
+		want    bool
+	}{
+		{"", "go1.4", true},
+		{">=1.21", "go1.21.0", true},
+		{">=1.21", "go1.21rc1", true},
+		{">=1.21", "go1.20.14", false},
+		{">=1.21,<1.23", "go1.22.5", true},
+		{">=1.21,<1.23", "go1.23.0", false},
+		{"1.22", "go1.22.5", true},
+		{"1.22", "go1.22rc1", true},
+		{"1.22", "go1.21.5", false},
+		{"go1.22.5", "go1.22.5", true},
+		{"1.22.5", "go1.22.6", false},
+		{"<=1.22.1", "go1.22.1", true},
+		{">1.22.1", "go1.22.1", false},
+	}
+	for _, tt := range tests {
+		f, err := parseVersionFilter(tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.match(tt.version); got != tt.want {
+			t.Errorf("%q matches %q = %v; want %v", tt.filter, tt.version, got, tt.want)
+		}
+	}
+	if _, err := parseVersionFilter(">=banana"); err == nil {
+		t.Errorf("parseVersionFilter accepted an invalid version")
+	}
+}
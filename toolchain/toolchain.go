@@ -0,0 +1,301 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package toolchain installs and runs binary releases of Go, sharing
+// the SDK roots and settings used by the goX wrapper commands.
+//
+// Versions are named as the wrapper commands are, such as "go1.22.5";
+// the "go" prefix may be omitted.
+//
+// Unlike the wrapper commands, Install does not read the settings that
+// change the installed tree from the environment: the zero Options
+// leave installed files writable and run no smoke test, whereas the
+// wrapper commands make them read-only unless GODL_READONLY is false
+// and check the go command unless GODL_SMOKE_TEST is off. Set
+// Options.ReadOnly and Options.SmokeTest to install the same trees as
+// the wrapper commands in the SDK roots they share.
+package toolchain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LetFu/dl/internal/version"
+)
+
+// Errors returned by this package can be examined with errors.Is and
+// errors.As.
+var (
+	// ErrNotFound reports that there is no binary release of the
+	// requested version for the host platform.
+	ErrNotFound = version.ErrNotFound
+
+	// ErrNotInstalled reports that the requested version is not
+	// installed.
+	ErrNotInstalled = version.ErrNotInstalled
+)
+
+// A ChecksumError reports that a downloaded archive does not have the
+// expected SHA-256 checksum.
+type ChecksumError = version.ChecksumError
+
+// A NetworkError reports a failure to fetch a URL: either the request
+// failed or was interrupted, or the server responded with an
+// unexpected status.
+type NetworkError = version.NetworkError
+
+// A Phase is a step of an install that reports progress.
+type Phase = version.Phase
+
+// The phases of an install.
+const (
+	PhaseDownload = version.PhaseDownload
+	PhaseVerify   = version.PhaseVerify
+	PhaseUnpack   = version.PhaseUnpack
+)
+
+// A ProgressEvent reports how much of a phase is done.
+type ProgressEvent = version.ProgressEvent
+
+// A Progress receives the progress of an install. Report is called
+// frequently and is expected to throttle its output.
+type Progress = version.Progress
+
+// NewProgress returns a Progress that writes to w in the given mode:
+// "tty" for a bar redrawn in place, "lines" for a line per second,
+// "ci" for a line each time another step percent is done, "json" for
+// newline-delimited JSON ProgressEvents, "quiet" for nothing, or "auto"
+// to choose based on w and the environment.
+func NewProgress(mode string, w io.Writer, step int) (Progress, error) {
+	return version.NewProgress(mode, w, step)
+}
+
+// A Profile selects the files of a distribution to install: all of them,
+// the minimal set needed to build programs, or those chosen by glob
+// patterns.
+type Profile = version.Profile
+
+// Options configures where and how versions are installed. The zero
+// value uses the same SDK roots and download site as the wrapper
+// commands and reports nothing.
+type Options struct {
+	// SDKRoot is the directory holding installed versions. If empty,
+	// the wrapper commands' SDK roots are used, as configured by the
+	// GODL_SDKROOT and GODL_SHAREDROOT settings.
+	SDKRoot string
+
+	// Mirror is the URL of the source of release archives: an http,
+	// https or file URL of a directory laid out like the official
+	// download site, or an s3+http or s3+https URL of a bucket.
+	// Several URLs separated by "|" are tried in turn, falling back to
+	// the next on network errors, server errors or corrupt archives,
+	// with checksums taken from ChecksumMirror. If empty, the sources
+	// are chosen by the GODL_SOURCES setting and default to the
+	// official download site.
+	Mirror string
+
+	// ChecksumMirror is the URL of the trusted source of archive
+	// checksums. If empty, it is chosen by the GODL_CHECKSUM_SOURCE
+	// setting, and otherwise defaults to the official download site.
+	// Set it to "mirror" to check each archive against the checksum
+	// served beside it, as for private builds the official site lacks.
+	ChecksumMirror string
+
+	// Client is used for all HTTP requests. If nil, a default client
+	// is used.
+	Client *http.Client
+
+	// Progress receives the progress of downloading, verifying and
+	// unpacking. If nil, progress is not reported.
+	Progress Progress
+
+	// Logger receives informational messages. If nil, they are
+	// discarded.
+	Logger *log.Logger
+
+	// IdleTimeout limits how long Install waits for more data from
+	// the server. Zero means no limit; use the context passed to
+	// Install to limit the whole install.
+	IdleTimeout time.Duration
+
+	// Profile selects the files to install. The zero value installs
+	// every file. Installing every file of a version installed with a
+	// profile that left some out adds them in place.
+	Profile Profile
+
+	// SmokeTest selects the checks that Install runs on the unpacked
+	// toolchain before marking it installed: "version" to check the
+	// version and platform its go command reports, "build" to also
+	// build a program, or "off" or empty for none, as set for the
+	// wrapper commands by GODL_SMOKE_TEST.
+	SmokeTest string
+
+	// ReadOnly makes Install remove write permission from the installed
+	// files, as the wrapper commands do unless GODL_READONLY is false.
+	// It defaults to false, so that the zero Options install trees that
+	// callers, such as tests, can remove without Remove. Remove
+	// restores write permission before removing them.
+	ReadOnly bool
+}
+
+// An Installation is a version of Go installed in an SDK root.
+type Installation struct {
+	Version string // such as "go1.22.5"
+	GOROOT  string // directory the version is installed in
+
+	// The following fields are recorded at install time. They are
+	// zero for versions installed before install receipts existed.
+	URL         string    // archive the version was installed from
+	Mirror      string    // source that served the archive
+	SHA256      string    // of the archive
+	Profile     Profile   // files installed; the zero value if all
+	InstalledAt time.Time // time the install completed
+}
+
+// Command returns a command that runs the go tool of the installation
+// with the given arguments.
+func (inst Installation) Command(ctx context.Context, args ...string) *exec.Cmd {
+	return version.GoCommand(ctx, inst.GOROOT, args...)
+}
+
+// Install installs the given version if it is not already installed,
+// and returns the installation.
+func Install(ctx context.Context, v string, opts Options) (Installation, error) {
+	v = normalize(v)
+	dir, err := opts.goroot(v)
+	if err != nil {
+		return Installation{}, err
+	}
+	in := &version.Installer{
+		BaseURL:     opts.Mirror,
+		ChecksumURL: opts.ChecksumMirror,
+		Client:      opts.Client,
+		Progress:    opts.Progress,
+		Logf:        func(string, ...any) {},
+
+		IdleTimeout: opts.IdleTimeout,
+		Profile:     opts.Profile,
+		SmokeTest:   opts.SmokeTest,
+		ReadOnly:    opts.ReadOnly,
+	}
+	if in.Progress == nil {
+		in.Progress, _ = NewProgress("quiet", nil, 0)
+	}
+	if opts.Logger != nil {
+		in.Logf = opts.Logger.Printf
+	}
+	if err := in.Install(ctx, dir, v); err != nil {
+		return Installation{}, fmt.Errorf("%s: %w", v, err)
+	}
+	return installation(v, dir), nil
+}
+
+// Resolve returns the installation of the given version, or an error if
+// it is not installed.
+func Resolve(v string, opts Options) (Installation, error) {
+	v = normalize(v)
+	dir, err := opts.goroot(v)
+	if err != nil {
+		return Installation{}, err
+	}
+	if !version.IsInstalled(dir) {
+		return Installation{}, fmt.Errorf("%s: %w in %s", v, ErrNotInstalled, filepath.Dir(dir))
+	}
+	return installation(v, dir), nil
+}
+
+// List returns the installed versions, oldest first, as the go command
+// orders versions, so that go1.9 comes before go1.10 and go1.21rc1
+// before go1.21.0. A version installed in more than one SDK root is
+// listed once, for the root that Resolve would use.
+func List(opts Options) ([]Installation, error) {
+	roots := []string{opts.SDKRoot}
+	if opts.SDKRoot == "" {
+		var err error
+		if roots, err = version.SDKRoots(); err != nil {
+			return nil, err
+		}
+	}
+	var list []Installation
+	seen := map[string]bool{}
+	for _, root := range roots {
+		entries, err := os.ReadDir(root)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			dir := filepath.Join(root, e.Name())
+			if !e.IsDir() || seen[e.Name()] || !version.IsInstalled(dir) {
+				continue
+			}
+			seen[e.Name()] = true
+			list = append(list, installation(e.Name(), dir))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return version.CompareVersions(list[i].Version, list[j].Version) < 0 })
+	return list, nil
+}
+
+// Remove removes the installation of the given version.
+func Remove(v string, opts Options) error {
+	inst, err := Resolve(v, opts)
+	if err != nil {
+		return err
+	}
+	return version.RemoveVersion(inst.GOROOT)
+}
+
+// Command returns a command that runs the go tool of the given version,
+// as installed in the SDK roots that opts selects, with the given
+// arguments. If the version is not installed, running the command
+// fails.
+func Command(ctx context.Context, v string, opts Options, args ...string) *exec.Cmd {
+	v = normalize(v)
+	dir, err := opts.goroot(v)
+	if err != nil {
+		// Leave a path that does not exist for Start to report.
+		dir = v
+	}
+	return version.GoCommand(ctx, dir, args...)
+}
+
+func (opts Options) goroot(v string) (string, error) {
+	if opts.SDKRoot != "" {
+		return filepath.Join(opts.SDKRoot, v), nil
+	}
+	return version.Goroot(v)
+}
+
+func normalize(v string) string {
+	if v != "" && !strings.HasPrefix(v, "go") {
+		return "go" + v
+	}
+	return v
+}
+
+func installation(v, dir string) Installation {
+	inst := Installation{Version: v, GOROOT: dir}
+	if r, err := version.ReadReceipt(dir); err == nil {
+		inst.URL = r.URL
+		inst.Mirror = r.Mirror
+		inst.SHA256 = r.SHA256
+		if r.Profile != nil {
+			inst.Profile = *r.Profile
+		}
+		inst.InstalledAt = r.InstalledAt
+	}
+	return inst
+}
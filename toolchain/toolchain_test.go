@@ -0,0 +1,159 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package toolchain
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRelease returns a tar.gz archive of a Go release whose go command
+// is a shell script that echoes its arguments.
+func fakeRelease(t *testing.T) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	files := []struct {
+		name, body string
+		mode       int64
+	}{
+		{"go/VERSION", "go1.99.1\n", 0644},
+		{"go/bin/go", "#!/bin/sh\necho fake go \"$@\"\n", 0755},
+	}
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: f.mode, Size: int64(len(f.body))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// fakeMirror starts a server of fakeRelease as the archive of each of
+// the given versions, and returns its URL.
+func fakeMirror(t *testing.T, archive []byte, versions ...string) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, v := range versions {
+			switch {
+			case !strings.HasPrefix(r.URL.Path, "/go/"+v+"."):
+			case strings.HasSuffix(r.URL.Path, ".tar.gz.sha256"):
+				fmt.Fprintf(w, "%x\n", sha256.Sum256(archive))
+				return
+			case strings.HasSuffix(r.URL.Path, ".tar.gz"):
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(archive))
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/go/"
+}
+
+func TestInstallResolveListRemove(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake release is a tar.gz with a shell script")
+	}
+	archive := fakeRelease(t)
+	ctx := context.Background()
+	opts := Options{SDKRoot: t.TempDir(), Mirror: fakeMirror(t, archive, "go1.99.1"), ChecksumMirror: "mirror"}
+	if _, err := Resolve("1.99.1", opts); !errors.Is(err, ErrNotInstalled) {
+		t.Fatalf("Resolve before Install = %v; want ErrNotInstalled", err)
+	}
+	inst, err := Install(ctx, "1.99.1", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst.Version != "go1.99.1" || inst.SHA256 != fmt.Sprintf("%x", sha256.Sum256(archive)) {
+		t.Errorf("Install returned %+v", inst)
+	}
+	if _, err := Install(ctx, "go1.99.2", opts); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Install of missing release = %v; want ErrNotFound", err)
+	}
+
+	got, err := Resolve("go1.99.1", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GOROOT != inst.GOROOT {
+		t.Errorf("Resolve GOROOT = %q; want %q", got.GOROOT, inst.GOROOT)
+	}
+	out, err := got.Command(ctx, "version").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "fake go version\n" {
+		t.Errorf("go version printed %q", out)
+	}
+
+	list, err := List(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Version != "go1.99.1" {
+		t.Errorf("List = %+v; want just go1.99.1", list)
+	}
+
+	if err := Remove("go1.99.1", opts); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := List(opts); len(list) != 0 {
+		t.Errorf("List after Remove = %+v; want none", list)
+	}
+}
+
+func TestListOrderAndCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake release is a tar.gz with a shell script")
+	}
+	versions := []string{"go1.9", "go1.10", "go1.21rc1", "go1.21.0"}
+	ctx := context.Background()
+	opts := Options{SDKRoot: t.TempDir(), Mirror: fakeMirror(t, fakeRelease(t), versions...), ChecksumMirror: "mirror"}
+	for _, v := range []string{"go1.21.0", "go1.10", "go1.21rc1", "go1.9"} {
+		if _, err := Install(ctx, v, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := List(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, inst := range list {
+		got = append(got, inst.Version)
+	}
+	if strings.Join(got, " ") != strings.Join(versions, " ") {
+		t.Errorf("List = %v; want %v", got, versions)
+	}
+
+	// Command finds versions in opts.SDKRoot, not the default roots.
+	t.Setenv("GODL_SDKROOT", t.TempDir())
+	out, err := Command(ctx, "1.10", opts, "version").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "fake go version\n" {
+		t.Errorf("go version printed %q", out)
+	}
+}